@@ -0,0 +1,218 @@
+package main
+
+// chitchat-admin is a command line client for the AdminService of a running ChitChat server
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "ChitChat/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+const usage = `Usage: chitchat-admin [flags] <command> [arguments]
+
+Commands:
+  sessions                     List the connected sessions
+  kick <username> [reason]     Disconnect a user
+  ban user <username> [reason] Ban a username and disconnect it
+  ban ip <address> [reason]    Ban an IP address and disconnect its sessions
+  announce <message>           Send a system announcement to every user
+  limit <length>               Change the maximum message length
+  stats                        Show server statistics
+  health [service]             Check whether the server accepts new sessions, no token needed
+
+Flags:
+`
+
+// readToken picks the admin token from the flag, the environment or the token file written by the server
+func readToken(token string, tokenFile string) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+
+	if env := os.Getenv("CHITCHAT_ADMIN_TOKEN"); env != "" {
+		return env, nil
+	}
+
+	contents, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("no admin token given and %v", err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func main() {
+	address := flag.String("server", "localhost:5001", "the address of the ChitChat server")
+	tokenFlag := flag.String("token", "", "the admin token, defaults to $CHITCHAT_ADMIN_TOKEN or the contents of the token file")
+	tokenFile := flag.String("token-file", "admin_token", "the file the server wrote its admin token to")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.NewClient(*address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fail("chitchat-admin: failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The health service is public, so that scripts can probe the server without the admin token
+	if args[0] == "health" {
+		if err := checkHealth(ctx, healthpb.NewHealthClient(conn), args[1:]); err != nil {
+			fail("chitchat-admin: %v", err)
+		}
+		return
+	}
+
+	token, err := readToken(*tokenFlag, *tokenFile)
+	if err != nil {
+		fail("chitchat-admin: %v", err)
+	}
+
+	admin := pb.NewAdminServiceClient(conn)
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("authorization", token))
+
+	if err := run(ctx, admin, args[0], args[1:]); err != nil {
+		fail("chitchat-admin: %v", err)
+	}
+}
+
+// checkHealth prints the serving status and fails unless the server is SERVING
+func checkHealth(ctx context.Context, client healthpb.HealthClient, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: health [service]")
+	}
+
+	service := ""
+	if len(args) == 1 {
+		service = args[0]
+	}
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(resp.Status)
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("the server is not serving")
+	}
+	return nil
+}
+
+func run(ctx context.Context, admin pb.AdminServiceClient, command string, args []string) error {
+	switch command {
+	case "sessions":
+		resp, err := admin.ListSessions(ctx, &pb.ListSessionsRequest{})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tROLE\tROOM\tIP\tCONNECTED\tSTREAMING")
+		for _, session := range resp.Sessions {
+			connected := time.Unix(session.ConnectedAt, 0).Format(time.DateTime)
+			fmt.Fprintf(w, "%s\t%s\t#%s\t%s\t%s\t%v\n", session.Username, session.Role, session.Room, session.Ip, connected, session.Streaming)
+		}
+		return w.Flush()
+
+	case "kick":
+		if len(args) < 1 {
+			return fmt.Errorf("usage: kick <username> [reason]")
+		}
+		_, err := admin.Kick(ctx, &pb.KickRequest{Username: args[0], Reason: strings.Join(args[1:], " ")})
+		if err == nil {
+			fmt.Printf("kicked %s\n", args[0])
+		}
+		return err
+
+	case "ban":
+		if len(args) < 2 || (args[0] != "user" && args[0] != "ip") {
+			return fmt.Errorf("usage: ban user <username> [reason] | ban ip <address> [reason]")
+		}
+
+		req := &pb.BanRequest{Reason: strings.Join(args[2:], " ")}
+		if args[0] == "user" {
+			req.Target = &pb.BanRequest_Username{Username: args[1]}
+		} else {
+			req.Target = &pb.BanRequest_Ip{Ip: args[1]}
+		}
+
+		resp, err := admin.Ban(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("banned %s %s\n", args[0], args[1])
+		for _, username := range resp.Kicked {
+			fmt.Printf("kicked %s\n", username)
+		}
+		return nil
+
+	case "announce":
+		if len(args) < 1 {
+			return fmt.Errorf("usage: announce <message>")
+		}
+		_, err := admin.Announce(ctx, &pb.AnnounceRequest{Message: strings.Join(args, " ")})
+		return err
+
+	case "limit":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: limit <length>")
+		}
+		limit, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid length: %s", args[0])
+		}
+
+		resp, err := admin.SetMessageLimit(ctx, &pb.SetMessageLimitRequest{Limit: uint32(limit)})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("message limit changed from %d to %d\n", resp.Previous, limit)
+		return nil
+
+	case "stats":
+		resp, err := admin.Stats(ctx, &pb.StatsRequest{})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "uptime\t%v\n", time.Duration(resp.UptimeSeconds)*time.Second)
+		fmt.Fprintf(w, "sessions\t%d\n", resp.Sessions)
+		fmt.Fprintf(w, "messages received\t%d\n", resp.MessagesReceived)
+		fmt.Fprintf(w, "messages broadcast\t%d\n", resp.MessagesBroadcast)
+		fmt.Fprintf(w, "messages dropped\t%d\n", resp.MessagesDropped)
+		fmt.Fprintf(w, "messages throttled\t%d\n", resp.MessagesThrottled)
+		fmt.Fprintf(w, "message limit\t%d\n", resp.MessageLimit)
+		fmt.Fprintf(w, "lamport time\t%d\n", resp.LamportTime)
+		return w.Flush()
+	}
+
+	return fmt.Errorf("unknown command %q, run chitchat-admin -h for help", command)
+}
@@ -4,8 +4,12 @@ import (
 	proto "ChitChat/grpc"
 	clocks "ChitChat/logical_clocks"
 	"context"
+	"fmt"
 	"io"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -18,27 +22,64 @@ const (
 	MessageEvent MessageKind = iota
 	LoginEvent
 	LogoutEvent
+	EditEvent
+	DeleteEvent
+	ReactionEvent
+	NickEvent
+	PrivateEvent
+	JoinEvent
+	MembersEvent
+	RejectedEvent
+	SystemEvent
+	KickEvent
+
+	// Local output of the client itself, e.g. from a slash command
+	NoticeEvent
 	ErrEvent
 )
 
+type Reaction struct {
+	reaction string
+	count    uint32
+}
+
 type ReceivedMessage struct {
 	event            MessageKind
 	author           string
 	message          string
 	lamportTimestamp uint64
+
+	// Server assigned ID of the chat message the event refers to
+	id        uint64
+	parentID  uint64
+	replies   uint
+	collapsed bool
+	edited    bool
+	deleted   bool
+	reactions []Reaction
+
+	room  string
+	emote bool
+	// The recipient of a private message, or the new username after a nick change
+	target  string
+	members []string
 }
 
 type Client struct {
 	conn     *grpc.ClientConn
 	client   proto.ChitChatServiceClient
 	stream   grpc.BidiStreamingClient[proto.StreamRequest, proto.StreamResponse]
+	ctx      context.Context
 	clock    *clocks.LamportClock
 	username string
+	room     string
 
 	messageCh chan ReceivedMessage
 }
 
-func NewClient(ip string, port string, username string, enableCallback bool) *Client {
+// NewClient connects with the username, and with the password from CHITCHAT_PASSWORD if the username needs one
+// The error is the status returned by the server if it refused the username
+func NewClient(ip string, port string, username string, enableCallback bool) (*Client, error) {
 
 	conn, err := grpc.NewClient(
 		ip+":"+port,
@@ -54,11 +95,11 @@ func NewClient(ip string, port string, username string, enableCallback bool) *Cl
 	client := proto.NewChitChatServiceClient(conn)
 
 	resp, err := client.Connect(context.Background(),
-		&proto.ConnectRequest{Username: username, Timestamp: clock.Now()})
+		&proto.ConnectRequest{Username: username, Password: os.Getenv("CHITCHAT_PASSWORD"), Timestamp: clock.Now()})
 
 	if err != nil {
 		conn.Close()
-		return nil
+		return nil, err
 	}
 
 	token := resp.GetToken()
@@ -78,7 +119,9 @@ func NewClient(ip string, port string, username string, enableCallback bool) *Cl
 		conn:     conn,
 		client:   client,
 		stream:   stream,
+		ctx:      ctxWithMetaData,
 		username: username,
+		room:     resp.GetRoom(),
 		clock:    clock,
 		messageCh: nil,
 	}
@@ -87,18 +130,199 @@ func NewClient(ip string, port string, username string, enableCallback bool) *Cl
 		go newClient.msgHandler()
 	}
 
-	return newClient
+	return newClient, nil
+}
+
+func (this *Client) sendRequest(req *proto.StreamRequest) error {
+	this.clock.Tick()
+	req.Timestamp = this.clock.Now()
+	return this.stream.Send(req)
 }
 
 func (this *Client) Send(message string) error {
+	return this.sendRequest(&proto.StreamRequest{Message: message})
+}
+
+func (this *Client) SendReply(parentID uint64, message string) error {
+	return this.sendRequest(&proto.StreamRequest{Message: message, ParentId: parentID})
+}
+
+func (this *Client) SendEmote(message string) error {
+	return this.sendRequest(&proto.StreamRequest{Message: message, Emote: true})
+}
+
+func (this *Client) SendPrivate(username string, message string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_PrivateRequest{
+			PrivateRequest: &proto.StreamRequest_Private{Username: username, Message: message},
+		},
+	})
+}
+
+func (this *Client) Nick(username string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_NickRequest{
+			NickRequest: &proto.StreamRequest_Nick{Username: username},
+		},
+	})
+}
+
+func (this *Client) Join(room string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_JoinRequest{
+			JoinRequest: &proto.StreamRequest_Join{Room: room},
+		},
+	})
+}
+
+// Who asks the server for the members of a room, the answer arrives as a MembersEvent
+func (this *Client) Who(room string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_WhoRequest{
+			WhoRequest: &proto.StreamRequest_Who{Room: room},
+		},
+	})
+}
+
+// Thread fetches a message and all of its replies from the server
+func (this *Client) Thread(id uint64) (ReceivedMessage, []ReceivedMessage, error) {
+	this.clock.Tick()
+	resp, err := this.client.GetThread(this.ctx,
+		&proto.ThreadRequest{Timestamp: this.clock.Now(), Id: id})
+
+	if err != nil {
+		return ReceivedMessage{}, nil, err
+	}
+
+	this.clock.Sync(clocks.From(resp.Timestamp))
+
+	parent := this.chatMessage(resp.Parent)
+	replies := make([]ReceivedMessage, 0, len(resp.Replies))
+	for _, reply := range resp.Replies {
+		replies = append(replies, this.chatMessage(reply))
+	}
+
+	return parent, replies, nil
+}
+
+// Export fetches a transcript of the conversation from the server
+func (this *Client) Export(req *proto.ExportRequest) ([]byte, uint32, error) {
 	this.clock.Tick()
-	err := this.stream.Send(
-		&proto.StreamRequest{
-			Timestamp: this.clock.Now(),
-			Message:   message,
-		})
+	req.Timestamp = this.clock.Now()
+
+	resp, err := this.client.Export(this.ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	this.clock.Sync(clocks.From(resp.Timestamp))
+	return resp.Transcript, resp.Events, nil
+}
+
+func (this *Client) chatMessage(message *proto.StreamResponse_Message) ReceivedMessage {
+	msg := ReceivedMessage{
+		event:            MessageEvent,
+		author:           message.Username,
+		message:          message.Message,
+		lamportTimestamp: this.clock.Now(),
+		id:               message.Id,
+		parentID:         message.ParentId,
+		emote:            message.Emote,
+		room:             message.Room,
+	}
+
+	if msg.author == this.Username() {
+		msg.author = "You"
+	}
+
+	return msg
+}
+
+func (this *Client) Edit(id uint64, message string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_EditRequest{
+			EditRequest: &proto.StreamRequest_Edit{Id: id, Message: message},
+		},
+	})
+}
+
+func (this *Client) Delete(id uint64) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_DeleteRequest{
+			DeleteRequest: &proto.StreamRequest_Delete{Id: id},
+		},
+	})
+}
+
+func (this *Client) React(id uint64, reaction string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_ReactRequest{
+			ReactRequest: &proto.StreamRequest_React{Id: id, Reaction: reaction},
+		},
+	})
+}
+
+// Mute silences a user for the given duration, only moderators and owners may do this
+func (this *Client) Mute(username string, duration time.Duration, reason string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_MuteRequest{
+			MuteRequest: &proto.StreamRequest_Mute{
+				Username:        username,
+				DurationSeconds: uint64(duration.Seconds()),
+				Reason:          reason,
+			},
+		},
+	})
+}
+
+func (this *Client) Unmute(username string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_UnmuteRequest{
+			UnmuteRequest: &proto.StreamRequest_Unmute{Username: username},
+		},
+	})
+}
+
+func (this *Client) Kick(username string, reason string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_KickRequest{
+			KickRequest: &proto.StreamRequest_Kick{Username: username, Reason: reason},
+		},
+	})
+}
+
+func (this *Client) Ban(username string, reason string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_BanRequest{
+			BanRequest: &proto.StreamRequest_Ban{Username: username, Reason: reason},
+		},
+	})
+}
+
+func (this *Client) Unban(username string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_UnbanRequest{
+			UnbanRequest: &proto.StreamRequest_Unban{Username: username},
+		},
+	})
+}
+
+// SetRole changes the role of a user, only the owner may do this
+func (this *Client) SetRole(username string, role proto.Role) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_RoleRequest{
+			RoleRequest: &proto.StreamRequest_SetRole{Username: username, Role: role},
+		},
+	})
+}
 
-	return err
+// SetPassword sets the password which is needed to connect with the current username from now on
+func (this *Client) SetPassword(password string) error {
+	return this.sendRequest(&proto.StreamRequest{
+		Action: &proto.StreamRequest_PasswordRequest{
+			PasswordRequest: &proto.StreamRequest_SetPassword{Password: password},
+		},
+	})
 }
 
 func (this *Client) recv() (ReceivedMessage, error) {
@@ -107,9 +331,9 @@ func (this *Client) recv() (ReceivedMessage, error) {
 	
 	if err == io.EOF {
 		this.stream.CloseSend()
-		return ReceivedMessage {ErrEvent, "", "", this.clock.Now()}, err
+		return ReceivedMessage {event: ErrEvent, lamportTimestamp: this.clock.Now()}, err
 	} else if resp == nil {
-		return ReceivedMessage {ErrEvent, "", "", this.clock.Now()}, err
+		return ReceivedMessage {event: ErrEvent, lamportTimestamp: this.clock.Now()}, err
 	}
 
 	this.clock.Sync(clocks.From(resp.Timestamp))
@@ -120,12 +344,68 @@ func (this *Client) recv() (ReceivedMessage, error) {
 		msg.event = MessageEvent
 		msg.message = ev.ChatMessage.Message
 		msg.author = ev.ChatMessage.Username
+		msg.id = ev.ChatMessage.Id
+		msg.parentID = ev.ChatMessage.ParentId
+		msg.emote = ev.ChatMessage.Emote
+		msg.room = ev.ChatMessage.Room
 	case *proto.StreamResponse_LoginEvent:
 		msg.event = LoginEvent
 		msg.author = ev.LoginEvent.Username
 	case *proto.StreamResponse_LogoutEvent:
 		msg.event = LogoutEvent
 		msg.author = ev.LogoutEvent.Username
+	case *proto.StreamResponse_EditEvent:
+		msg.event = EditEvent
+		msg.message = ev.EditEvent.Message
+		msg.author = ev.EditEvent.Username
+		msg.id = ev.EditEvent.Id
+	case *proto.StreamResponse_DeleteEvent:
+		msg.event = DeleteEvent
+		msg.author = ev.DeleteEvent.Username
+		msg.id = ev.DeleteEvent.Id
+	case *proto.StreamResponse_ReactionEvent:
+		msg.event = ReactionEvent
+		msg.author = ev.ReactionEvent.Username
+		msg.id = ev.ReactionEvent.Id
+		for _, count := range ev.ReactionEvent.Counts {
+			msg.reactions = append(msg.reactions, Reaction{count.Reaction, count.Count})
+		}
+	case *proto.StreamResponse_ErrorEvent:
+		msg.event = RejectedEvent
+		msg.message = ev.ErrorEvent.Reason
+	case *proto.StreamResponse_NickEvent:
+		msg.event = NickEvent
+		msg.author = ev.NickEvent.OldUsername
+		msg.target = ev.NickEvent.NewUsername
+		if msg.author == this.username {
+			this.username = msg.target
+			msg.author = "You"
+		}
+	case *proto.StreamResponse_PrivateEvent:
+		msg.event = PrivateEvent
+		msg.author = ev.PrivateEvent.From
+		msg.target = ev.PrivateEvent.To
+		msg.message = ev.PrivateEvent.Message
+		if msg.target == this.username {
+			msg.target = "You"
+		}
+	case *proto.StreamResponse_JoinEvent:
+		msg.event = JoinEvent
+		msg.author = ev.JoinEvent.Username
+		msg.room = ev.JoinEvent.Room
+		if msg.author == this.username {
+			this.room = msg.room
+		}
+	case *proto.StreamResponse_SystemEvent:
+		msg.event = SystemEvent
+		msg.message = ev.SystemEvent.Message
+	case *proto.StreamResponse_KickEvent:
+		msg.event = KickEvent
+		msg.message = ev.KickEvent.Reason
+	case *proto.StreamResponse_MembersEvent:
+		msg.event = MembersEvent
+		msg.room = ev.MembersEvent.Room
+		msg.members = ev.MembersEvent.Usernames
 	}
 
 	msg.lamportTimestamp = this.clock.Now()
@@ -137,6 +417,15 @@ func (this *Client) recv() (ReceivedMessage, error) {
 	return msg, nil
 }
 
+// ReactionSummary renders the reactions of a message compactly, e.g. "👍 2  ok 1"
+func (msg *ReceivedMessage) ReactionSummary() string {
+	parts := make([]string, 0, len(msg.reactions))
+	for _, r := range msg.reactions {
+		parts = append(parts, fmt.Sprintf("%s %d", r.reaction, r.count))
+	}
+	return strings.Join(parts, "  ")
+}
+
 func (this *Client) msgHandler() {
 	for {
 		resp, _ := this.recv()
@@ -154,6 +443,10 @@ func (this *Client) Username() string {
 	return this.username
 }
 
+func (this *Client) Room() string {
+	return this.room
+}
+
 func (this *Client) SetMessageChannel(ch chan ReceivedMessage) {
 	this.messageCh = ch
 }
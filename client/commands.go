@@ -0,0 +1,373 @@
+package main
+
+// Slash commands shared by both client front-ends
+// Lines starting with '/' are parsed as commands instead of being sent as chat, a leading "//" sends a literal '/'
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	proto "ChitChat/grpc"
+)
+
+// CommandEnv is implemented by the client front-ends so that commands can interact with them
+type CommandEnv interface {
+	Client() *Client
+	// Notice shows local output to the user without sending anything to the server
+	Notice(text string)
+	Clear()
+	Quit()
+}
+
+type Command struct {
+	Name    string
+	Args    string
+	Summary string
+
+	// The last argument consumes the remainder of the line, so it may contain spaces
+	MinArgs int
+	MaxArgs int
+
+	Run func(env CommandEnv, args []string) error
+}
+
+type CommandRegistry struct {
+	commands map[string]*Command
+	order    []string
+}
+
+var ErrUnknownCommand = errors.New("unknown command, type /help for a list of commands")
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{commands: make(map[string]*Command)}
+}
+
+func (r *CommandRegistry) Register(cmd *Command) {
+	if _, exists := r.commands[cmd.Name]; !exists {
+		r.order = append(r.order, cmd.Name)
+	}
+	r.commands[cmd.Name] = cmd
+}
+
+func (r *CommandRegistry) Lookup(name string) (*Command, bool) {
+	cmd, exists := r.commands[strings.TrimPrefix(name, "/")]
+	return cmd, exists
+}
+
+// Names returns the names of all commands in the order they were registered
+func (r *CommandRegistry) Names() []string {
+	return r.order
+}
+
+func (cmd *Command) Usage() string {
+	if cmd.Args == "" {
+		return "/" + cmd.Name
+	}
+	return "/" + cmd.Name + " " + cmd.Args
+}
+
+// IsCommand reports whether the line should be handled as a command rather than sent as chat
+func IsCommand(line string) bool {
+	return strings.HasPrefix(line, "/") && !strings.HasPrefix(line, "//")
+}
+
+// ChatText returns the text to send for a line which is not a command
+func ChatText(line string) string {
+	if strings.HasPrefix(line, "//") {
+		return line[1:]
+	}
+	return line
+}
+
+// ParseCommand splits a command line into the command name and the unparsed arguments
+func ParseCommand(line string) (string, string, bool) {
+	if !IsCommand(line) {
+		return "", "", false
+	}
+
+	name, rest, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	return name, strings.TrimSpace(rest), true
+}
+
+// splitArgs splits the arguments by whitespace into at most n parts, the last part keeps its inner spaces
+func splitArgs(rest string, n int) []string {
+	args := []string{}
+	rest = strings.TrimSpace(rest)
+
+	for rest != "" && len(args) < n-1 {
+		arg, remainder, _ := strings.Cut(rest, " ")
+		args = append(args, arg)
+		rest = strings.TrimSpace(remainder)
+	}
+
+	// For commands without arguments the leftover is kept, so that too many arguments are detected
+	if rest != "" {
+		args = append(args, rest)
+	}
+
+	return args
+}
+
+// Execute parses the line and runs the matching command
+func (r *CommandRegistry) Execute(env CommandEnv, line string) error {
+	name, rest, ok := ParseCommand(line)
+	if !ok {
+		return ErrUnknownCommand
+	}
+
+	cmd, exists := r.Lookup(name)
+	if !exists {
+		return ErrUnknownCommand
+	}
+
+	args := splitArgs(rest, cmd.MaxArgs)
+	if len(args) < cmd.MinArgs || len(args) > cmd.MaxArgs {
+		return fmt.Errorf("usage: %s", cmd.Usage())
+	}
+
+	return cmd.Run(env, args)
+}
+
+// parseMessageID accepts message IDs both as "12" and "#12"
+func parseMessageID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid message ID: %s", arg)
+	}
+	return id, nil
+}
+
+// optionalArg returns the argument at index i, or "" if it was left out
+func optionalArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// DefaultCommands returns a registry with all the built-in commands
+func DefaultCommands() *CommandRegistry {
+	r := NewCommandRegistry()
+
+	r.Register(&Command{
+		Name: "help", Args: "[command]", Summary: "List the commands or show the usage of one",
+		MinArgs: 0, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			if len(args) == 1 {
+				cmd, exists := r.Lookup(args[0])
+				if !exists {
+					return ErrUnknownCommand
+				}
+				env.Notice(fmt.Sprintf("%s - %s", cmd.Usage(), cmd.Summary))
+				return nil
+			}
+
+			for _, name := range r.Names() {
+				cmd := r.commands[name]
+				env.Notice(fmt.Sprintf("%s - %s", cmd.Usage(), cmd.Summary))
+			}
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name: "quit", Summary: "Leave the chat and exit",
+		Run: func(env CommandEnv, args []string) error {
+			env.Quit()
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name: "clear", Summary: "Clear the messages on screen",
+		Run: func(env CommandEnv, args []string) error {
+			env.Clear()
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name: "nick", Args: "<username>", Summary: "Change your username",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			if strings.ContainsAny(args[0], " \t") {
+				return errors.New("usernames can not contain spaces")
+			}
+			return env.Client().Nick(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "me", Args: "<action>", Summary: "Describe what you are doing, e.g. /me waves",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().SendEmote(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "msg", Args: "<user> <message>", Summary: "Send a private message",
+		MinArgs: 2, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().SendPrivate(args[0], args[1])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "join", Args: "<room>", Summary: "Switch to another room",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			if strings.ContainsAny(args[0], " \t") {
+				return errors.New("room names can not contain spaces")
+			}
+			return env.Client().Join(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "who", Args: "[room]", Summary: "List the users in your room or another room",
+		MinArgs: 0, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			room := ""
+			if len(args) == 1 {
+				room = args[0]
+			}
+			return env.Client().Who(room)
+		},
+	})
+
+	r.Register(&Command{
+		Name: "reply", Args: "<id> <message>", Summary: "Reply to a message in its thread",
+		MinArgs: 2, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			id, err := parseMessageID(args[0])
+			if err != nil {
+				return err
+			}
+			return env.Client().SendReply(id, args[1])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "edit", Args: "<id> <message>", Summary: "Edit one of your messages",
+		MinArgs: 2, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			id, err := parseMessageID(args[0])
+			if err != nil {
+				return err
+			}
+			return env.Client().Edit(id, args[1])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "delete", Args: "<id>", Summary: "Delete one of your messages",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			id, err := parseMessageID(args[0])
+			if err != nil {
+				return err
+			}
+			return env.Client().Delete(id)
+		},
+	})
+
+	r.Register(&Command{
+		Name: "react", Args: "<id> <reaction>", Summary: "React to a message, reacting twice removes the reaction",
+		MinArgs: 2, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			id, err := parseMessageID(args[0])
+			if err != nil {
+				return err
+			}
+			return env.Client().React(id, args[1])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "mute", Args: "<user> <duration> [reason]", Summary: "Stop a user from talking for a while, e.g. /mute bob 10m",
+		MinArgs: 2, MaxArgs: 3,
+		Run: func(env CommandEnv, args []string) error {
+			duration, err := time.ParseDuration(args[1])
+			if err != nil || duration < time.Second {
+				return fmt.Errorf("invalid duration: %s", args[1])
+			}
+			return env.Client().Mute(args[0], duration, optionalArg(args, 2))
+		},
+	})
+
+	r.Register(&Command{
+		Name: "unmute", Args: "<user>", Summary: "Let a muted user talk again",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().Unmute(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "kick", Args: "<user> [reason]", Summary: "Disconnect a user",
+		MinArgs: 1, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().Kick(args[0], optionalArg(args, 1))
+		},
+	})
+
+	r.Register(&Command{
+		Name: "ban", Args: "<user> [reason]", Summary: "Ban a username and disconnect it",
+		MinArgs: 1, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().Ban(args[0], optionalArg(args, 1))
+		},
+	})
+
+	r.Register(&Command{
+		Name: "unban", Args: "<user>", Summary: "Lift the ban on a username",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().Unban(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "role", Args: "<user> <owner|moderator|member>", Summary: "Change the role of a user",
+		MinArgs: 2, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			role, exists := proto.Role_value[strings.ToUpper(args[1])]
+			if !exists {
+				return fmt.Errorf("invalid role: %s", args[1])
+			}
+			return env.Client().SetRole(args[0], proto.Role(role))
+		},
+	})
+
+	r.Register(&Command{
+		Name: "password", Args: "<password>", Summary: "Require a password to connect with your username, see CHITCHAT_PASSWORD",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			return env.Client().SetPassword(args[0])
+		},
+	})
+
+	r.Register(&Command{
+		Name: "export", Args: "<file> [room=<room>] [user=<user>] [since=<time>] [until=<time>]",
+		Summary: "Save the conversation as .jsonl, .md or .html, times are e.g. 2h or 2006-01-02T15:04",
+		MinArgs: 1, MaxArgs: 2,
+		Run: func(env CommandEnv, args []string) error {
+			return exportTranscript(env, args[0], optionalArg(args, 1))
+		},
+	})
+
+	r.Register(&Command{
+		Name: "search", Args: "<query>",
+		Summary: "Search the message history, e.g. deploy from:bob in:dev since:2h",
+		MinArgs: 1, MaxArgs: 1,
+		Run: func(env CommandEnv, args []string) error {
+			return searchNotices(env, args[0])
+		},
+	})
+
+	return r
+}
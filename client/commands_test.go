@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testEnv records what the commands do to the front-end, it has no client
+type testEnv struct {
+	notices []string
+	cleared bool
+	quit    bool
+}
+
+func (env *testEnv) Client() *Client    { return nil }
+func (env *testEnv) Notice(text string) { env.notices = append(env.notices, text) }
+func (env *testEnv) Clear()             { env.cleared = true }
+func (env *testEnv) Quit()              { env.quit = true }
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		rest string
+		ok   bool
+	}{
+		{"/nick bob", "nick", "bob", true},
+		{"/msg bob  hi  there ", "msg", "bob  hi  there", true},
+		{"/quit", "quit", "", true},
+		{"/", "", "", true},
+		{"hello", "", "", false},
+		{" /quit", "", "", false},
+		// A double slash escapes a message starting with a slash
+		{"//quit", "", "", false},
+	}
+
+	for _, test := range tests {
+		name, rest, ok := ParseCommand(test.line)
+		if name != test.name || rest != test.rest || ok != test.ok {
+			t.Errorf("ParseCommand(%q) = %q, %q, %v, want %q, %q, %v", test.line, name, rest, ok, test.name, test.rest, test.ok)
+		}
+	}
+}
+
+func TestChatText(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"hello", "hello"},
+		{"//quit", "/quit"},
+		{"///", "//"},
+		{"a // b", "a // b"},
+	}
+
+	for _, test := range tests {
+		if IsCommand(test.line) {
+			t.Errorf("IsCommand(%q) = true", test.line)
+		}
+		if got := ChatText(test.line); got != test.want {
+			t.Errorf("ChatText(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		rest string
+		n    int
+		want []string
+	}{
+		{"", 2, []string{}},
+		{"a", 2, []string{"a"}},
+		{"a b", 2, []string{"a", "b"}},
+		// The last argument keeps its inner spaces
+		{"a  b c ", 2, []string{"a", "b c"}},
+		{"bob hello  there", 1, []string{"bob hello  there"}},
+		// Commands without arguments keep the leftover, so that it is refused
+		{"foo bar", 0, []string{"foo bar"}},
+		{"  ", 0, []string{}},
+	}
+
+	for _, test := range tests {
+		if got := splitArgs(test.rest, test.n); !slices.Equal(got, test.want) {
+			t.Errorf("splitArgs(%q, %d) = %q, want %q", test.rest, test.n, got, test.want)
+		}
+	}
+}
+
+func TestCommandRegistryExecute(t *testing.T) {
+	var ran []string
+	record := func(env CommandEnv, args []string) error {
+		ran = args
+		return nil
+	}
+
+	r := NewCommandRegistry()
+	r.Register(&Command{Name: "none", Run: record})
+	r.Register(&Command{Name: "one", Args: "<text>", MinArgs: 1, MaxArgs: 1, Run: record})
+	r.Register(&Command{Name: "range", Args: "<a> [b]", MinArgs: 1, MaxArgs: 2, Run: record})
+
+	tests := []struct {
+		line string
+		args []string
+		err  string
+	}{
+		{"/none", []string{}, ""},
+		{"/none foo", nil, "usage: /none"},
+		{"/one", nil, "usage: /one <text>"},
+		{"/one a  b c", []string{"a  b c"}, ""},
+		{"/range", nil, "usage: /range <a> [b]"},
+		{"/range a", []string{"a"}, ""},
+		{"/range a  b c", []string{"a", "b c"}, ""},
+		{"/missing", nil, ErrUnknownCommand.Error()},
+		{"/", nil, ErrUnknownCommand.Error()},
+		{"//none", nil, ErrUnknownCommand.Error()},
+	}
+
+	for _, test := range tests {
+		ran = nil
+		err := r.Execute(&testEnv{}, test.line)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Execute(%q) = %v, want %q", test.line, err, test.err)
+			}
+			if ran != nil {
+				t.Errorf("Execute(%q) ran the command with %q", test.line, ran)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(ran, test.args) {
+			t.Errorf("Execute(%q) ran with %q, %v, want %q", test.line, ran, err, test.args)
+		}
+	}
+}
+
+func TestDefaultCommandsArity(t *testing.T) {
+	commands := DefaultCommands()
+
+	env := &testEnv{}
+	if err := commands.Execute(env, "/quit foo"); err == nil || env.quit {
+		t.Errorf("/quit foo = %v and quit %v, want a usage error", err, env.quit)
+	}
+	if err := commands.Execute(env, "/quit"); err != nil || !env.quit {
+		t.Errorf("/quit = %v and quit %v", err, env.quit)
+	}
+
+	env = &testEnv{}
+	if err := commands.Execute(env, "/help nick"); err != nil || len(env.notices) != 1 || !strings.HasPrefix(env.notices[0], "/nick <username>") {
+		t.Errorf("/help nick = %v, %q", err, env.notices)
+	}
+	if err := commands.Execute(env, "/help nosuchcommand"); !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("/help nosuchcommand = %v, want %v", err, ErrUnknownCommand)
+	}
+}
+
+func TestParseMessageID(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want uint64
+		ok   bool
+	}{
+		{"12", 12, true},
+		{"#12", 12, true},
+		{"#0", 0, false},
+		{"0", 0, false},
+		{"##12", 0, false},
+		{"-1", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+	}
+
+	for _, test := range tests {
+		id, err := parseMessageID(test.arg)
+		if id != test.want || (err == nil) != test.ok {
+			t.Errorf("parseMessageID(%q) = %d, %v, want %d", test.arg, id, err, test.want)
+		}
+	}
+}
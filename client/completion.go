@@ -0,0 +1,182 @@
+package main
+
+// Completion of usernames, rooms and commands, shared by both client front-ends
+// The TUI completes the word before the cursor on Tab, the plain client lists the completions of a line ending in a tab
+
+import (
+	"sort"
+	"strings"
+)
+
+// completionKind is what an argument of a command is completed with
+type completionKind uint8
+
+const (
+	completeNothing completionKind = iota
+	completeUsers
+	completeRooms
+	completeCommands
+	completeChoices
+)
+
+// Completer keeps track of the users and rooms seen in the chat
+type Completer struct {
+	commands *CommandRegistry
+	users    map[string]bool
+	rooms    map[string]bool
+
+	// Returns our own username, which is never offered, it changes with /nick
+	Self func() string
+}
+
+func NewCompleter(commands *CommandRegistry) *Completer {
+	return &Completer{
+		commands: commands,
+		users:    make(map[string]bool),
+		rooms:    map[string]bool{"general": true},
+	}
+}
+
+// Observe updates the known users and rooms from a received event
+func (c *Completer) Observe(msg *ReceivedMessage) {
+	addUser := func(username string) {
+		// Our own messages are shown as coming from "You"
+		if username != "" && username != "You" {
+			c.users[username] = true
+		}
+	}
+
+	switch msg.event {
+	case LoginEvent, MessageEvent, PrivateEvent:
+		addUser(msg.author)
+	case LogoutEvent:
+		delete(c.users, msg.author)
+	case NickEvent:
+		delete(c.users, msg.author)
+		addUser(msg.target)
+	case JoinEvent:
+		addUser(msg.author)
+	case MembersEvent:
+		for _, username := range msg.members {
+			addUser(username)
+		}
+	}
+
+	if msg.room != "" {
+		c.rooms[msg.room] = true
+	}
+}
+
+// argumentKind derives what an argument is completed with from its description in the usage of the command
+// e.g. "<user>" completes users and "<owner|moderator|member>" one of the choices
+func argumentKind(arg string) (completionKind, []string) {
+	name := strings.Trim(arg, "<>[]")
+	switch {
+	case name == "user":
+		return completeUsers, nil
+	case name == "room":
+		return completeRooms, nil
+	case name == "command":
+		return completeCommands, nil
+	case strings.Contains(name, "|"):
+		return completeChoices, strings.Split(name, "|")
+	case name == "message" || name == "action" || name == "reason" || name == "query":
+		// Free text, which often mentions other users
+		return completeUsers, nil
+	}
+	return completeNothing, nil
+}
+
+// Complete returns the completions of the word at the end of the text, which is the input before the cursor
+// Returns the byte offset the word starts at and the candidates which replace it, sorted
+func (c *Completer) Complete(before string) (int, []string) {
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+
+	kind, choices := completeUsers, []string(nil)
+	prefix := ""
+	switch {
+	case start == 0 && IsCommand(word):
+		kind, prefix = completeCommands, "/"
+	case strings.HasPrefix(word, "#"):
+		kind, prefix = completeRooms, "#"
+	case strings.HasPrefix(word, "in:"):
+		// The filters of a search query
+		kind, prefix = completeRooms, "in:"
+	case strings.HasPrefix(word, "from:"):
+		kind, prefix = completeUsers, "from:"
+	case IsCommand(before):
+		name, _, _ := ParseCommand(before)
+		cmd, exists := c.commands.Lookup(name)
+		if !exists {
+			return start, nil
+		}
+
+		// The word is the n-th argument, the last argument takes the rest of the line
+		n := len(strings.Fields(before[:start])) - 1
+		args := strings.Fields(cmd.Args)
+		if len(args) == 0 {
+			return start, nil
+		}
+		kind, choices = argumentKind(args[min(n, len(args)-1)])
+	}
+
+	var names []string
+	switch kind {
+	case completeUsers:
+		for name := range c.users {
+			if c.Self == nil || name != c.Self() {
+				names = append(names, name)
+			}
+		}
+	case completeRooms:
+		names = keys(c.rooms)
+	case completeCommands:
+		names = append(names, c.commands.Names()...)
+	case completeChoices:
+		names = choices
+	}
+
+	partial := strings.ToLower(strings.TrimPrefix(word, prefix))
+	candidates := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(strings.ToLower(name), partial) {
+			candidates = append(candidates, prefix+name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return start, candidates
+}
+
+// Users returns the users seen in the chat, sorted
+func (c *Completer) Users() []string {
+	names := keys(c.users)
+	sort.Strings(names)
+	return names
+}
+
+// Rooms returns the rooms seen in the chat, sorted
+func (c *Completer) Rooms() []string {
+	names := keys(c.rooms)
+	sort.Strings(names)
+	return names
+}
+
+func keys(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	return names
+}
+
+// completionNotice lists the completions of the last word of a line, for the plain client which can not edit the line
+func completionNotice(env CommandEnv, completer *Completer, line string) {
+	_, candidates := completer.Complete(line)
+	if len(candidates) == 0 {
+		env.Notice("No completions")
+		return
+	}
+	env.Notice("Completions: " + strings.Join(candidates, "  "))
+}
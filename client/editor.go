@@ -0,0 +1,391 @@
+package main
+
+import (
+	"ChitChat/ui"
+	utils "ChitChat/utils"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// killRingSize is the number of killed texts kept for yanking
+const killRingSize = 16
+
+// LineEditor is the input of the TUI with Emacs style editing
+// The text may span several lines, Home, End and the kill commands work on the line the cursor is on
+type LineEditor struct {
+	buffer *utils.TextBuffer
+
+	// Killed text, newest last. Consecutive kills are joined into one entry, like in Emacs
+	killRing []string
+	lastKill bool
+
+	// The kill ring entry inserted by the last command if it was a yank, or -1, and where it was inserted
+	yanked    int
+	yankStart uint
+}
+
+// NewLineEditor creates an editor which holds at most limit characters, or any number if the limit is 0
+func NewLineEditor(limit uint) *LineEditor {
+	return &LineEditor{buffer: utils.NewTextBuffer(limit), yanked: -1}
+}
+
+func (this *LineEditor) String() string {
+	return this.buffer.String()
+}
+
+func (this *LineEditor) Len() uint {
+	return this.buffer.Len()
+}
+
+func (this *LineEditor) Cursor() uint {
+	return this.buffer.Cursor()
+}
+
+// BeforeCursor returns the text to the left of the cursor
+func (this *LineEditor) BeforeCursor() string {
+	text, _ := this.buffer.Slice(0, this.Cursor())
+	return text
+}
+
+// Selection returns the start and the end of the selected text, false if nothing is selected
+func (this *LineEditor) Selection() (uint, uint, bool) {
+	return this.buffer.Selection()
+}
+
+// Reset clears the line along with its undo history
+func (this *LineEditor) Reset() {
+	this.buffer.Reset()
+}
+
+// Set replaces the line, the cursor is moved to its end
+func (this *LineEditor) Set(text string) {
+	this.Reset()
+	this.InsertText(text)
+}
+
+// Insert inserts the character at the cursor, replacing the selection. Returns false if the line is full
+func (this *LineEditor) Insert(ch rune) bool {
+	return this.InsertText(string(ch))
+}
+
+// InsertText inserts as much of the text at the cursor as fits, replacing the selection
+// Line breaks are kept as '\n', tabs become spaces and other control characters are dropped
+// Returns false if not all of it fitted
+func (this *LineEditor) InsertText(text string) bool {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.Map(func(ch rune) rune {
+		switch {
+		case ch == '\r':
+			return '\n'
+		case ch == '\t':
+			return ' '
+		case ch != '\n' && (ch < ' ' || ch == 0x7f):
+			return -1
+		}
+		return ch
+	}, text)
+
+	from, to, selected := this.buffer.Selection()
+	if !selected {
+		from, to = this.Cursor(), this.Cursor()
+	}
+	return this.buffer.Replace(from, to, text) == nil
+}
+
+// deleteRange removes the characters between the two positions and returns them, the cursor is moved to from
+func (this *LineEditor) deleteRange(from uint, to uint) string {
+	removed, err := this.buffer.Delete(from, to)
+	if err != nil {
+		log.Printf("Failed to edit the input: %v", err)
+	}
+	return removed
+}
+
+// Replace replaces the characters between the two positions with the text, the cursor is moved after it
+func (this *LineEditor) Replace(from uint, to uint, text string) {
+	this.deleteRange(from, to)
+	this.InsertText(text)
+}
+
+// Backspace deletes the character before the cursor, or the selection
+func (this *LineEditor) Backspace() {
+	if this.buffer.DeleteSelection() != "" {
+		return
+	}
+	if this.Cursor() > 0 {
+		this.deleteRange(this.Cursor()-1, this.Cursor())
+	}
+}
+
+// Delete deletes the character under the cursor, or the selection
+func (this *LineEditor) Delete() {
+	if this.buffer.DeleteSelection() != "" {
+		return
+	}
+	if this.Cursor() < this.Len() {
+		this.deleteRange(this.Cursor(), this.Cursor()+1)
+	}
+}
+
+func (this *LineEditor) Left() {
+	this.buffer.Left()
+}
+
+func (this *LineEditor) Right() {
+	this.buffer.Right()
+}
+
+// Home moves the cursor to the start of its line
+func (this *LineEditor) Home() {
+	this.buffer.SetCursor(this.buffer.LineStart(this.Cursor()))
+}
+
+// End moves the cursor to the end of its line
+func (this *LineEditor) End() {
+	this.buffer.SetCursor(this.buffer.LineEnd(this.Cursor()))
+}
+
+// Up moves the cursor to the same column of the previous line, returns false if it is on the first line
+func (this *LineEditor) Up() bool {
+	start := this.buffer.LineStart(this.Cursor())
+	if start == 0 {
+		return false
+	}
+
+	column := this.Cursor() - start
+	previous := this.buffer.LineStart(start - 1)
+	this.buffer.ClearSelection()
+	this.buffer.SetCursor(min(previous+column, start-1))
+	return true
+}
+
+// Down moves the cursor to the same column of the next line, returns false if it is on the last line
+func (this *LineEditor) Down() bool {
+	end := this.buffer.LineEnd(this.Cursor())
+	if end == this.Len() {
+		return false
+	}
+
+	column := this.Cursor() - this.buffer.LineStart(this.Cursor())
+	this.buffer.ClearSelection()
+	this.buffer.SetCursor(min(end+1+column, this.buffer.LineEnd(end+1)))
+	return true
+}
+
+// Undo reverts the last edit, a word typed counts as one edit
+func (this *LineEditor) Undo() {
+	this.buffer.Undo()
+}
+
+func (this *LineEditor) Redo() {
+	this.buffer.Redo()
+}
+
+func isWordRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+func isNotSpace(ch rune) bool {
+	return !unicode.IsSpace(ch)
+}
+
+func (this *LineEditor) WordLeft() {
+	this.buffer.SetCursor(this.buffer.WordStart(this.Cursor(), isWordRune))
+}
+
+func (this *LineEditor) WordRight() {
+	this.buffer.SetCursor(this.buffer.WordEnd(this.Cursor(), isWordRune))
+}
+
+// kill removes the text between the positions and adds it to the kill ring
+func (this *LineEditor) kill(from uint, to uint) {
+	if from == to {
+		return
+	}
+
+	backwards := to <= this.Cursor()
+	this.addKill(this.deleteRange(from, to), backwards)
+}
+
+// addKill adds the text to the kill ring, or to its newest entry if the last command was a kill too
+func (this *LineEditor) addKill(text string, backwards bool) {
+	last := len(this.killRing) - 1
+	switch {
+	case this.lastKill && backwards:
+		this.killRing[last] = text + this.killRing[last]
+	case this.lastKill:
+		this.killRing[last] += text
+	default:
+		this.killRing = append(this.killRing, text)
+		if len(this.killRing) > killRingSize {
+			this.killRing = this.killRing[1:]
+		}
+	}
+}
+
+// KillWordBackward kills the whitespace separated word before the cursor, like Ctrl-W in a shell
+// If text is selected the selection is killed instead
+func (this *LineEditor) KillWordBackward() {
+	if from, to, selected := this.Selection(); selected {
+		this.kill(from, to)
+		return
+	}
+	this.kill(this.buffer.WordStart(this.Cursor(), isNotSpace), this.Cursor())
+}
+
+// KillWordForward kills the word after the cursor
+func (this *LineEditor) KillWordForward() {
+	this.kill(this.Cursor(), this.buffer.WordEnd(this.Cursor(), isWordRune))
+}
+
+// KillToStart kills the text between the start of the line and the cursor
+func (this *LineEditor) KillToStart() {
+	this.kill(this.buffer.LineStart(this.Cursor()), this.Cursor())
+}
+
+// KillToEnd kills the rest of the line, or the line break if the cursor is at the end of the line
+func (this *LineEditor) KillToEnd() {
+	end := this.buffer.LineEnd(this.Cursor())
+	if end == this.Cursor() && end < this.Len() {
+		end++
+	}
+	this.kill(this.Cursor(), end)
+}
+
+// CopySelection adds the selected text to the kill ring without removing it
+func (this *LineEditor) CopySelection() {
+	if text := this.buffer.SelectedText(); text != "" {
+		this.addKill(text, false)
+		this.buffer.ClearSelection()
+	}
+}
+
+// Yank inserts the most recently killed text
+func (this *LineEditor) Yank() {
+	if len(this.killRing) == 0 {
+		return
+	}
+
+	this.yankStart = this.Cursor()
+	this.yanked = len(this.killRing) - 1
+	this.InsertText(this.killRing[this.yanked])
+}
+
+// YankPop replaces the text inserted by the previous yank with the kill before it
+func (this *LineEditor) YankPop() {
+	if this.yanked < 0 {
+		return
+	}
+
+	this.deleteRange(this.yankStart, this.Cursor())
+	this.yanked = (this.yanked + len(this.killRing) - 1) % len(this.killRing)
+	this.InsertText(this.killRing[this.yanked])
+}
+
+// move runs a cursor movement, with Shift held it extends the selection, otherwise the selection is dropped
+func (this *LineEditor) move(key ui.Key, movement func()) {
+	if key.HasModifier(ui.ModShift) {
+		this.buffer.StartSelection()
+	} else {
+		this.buffer.ClearSelection()
+	}
+	movement()
+}
+
+// HandleKey applies an editing key to the line, returns false if the key is not an editing key
+func (this *LineEditor) HandleKey(key ui.Key) bool {
+	killed, yanked := false, -1
+	defer func() {
+		this.lastKill = killed
+		this.yanked = yanked
+	}()
+
+	if key.IsSpecial() {
+		switch key.GetSpecial() {
+		case ui.Backspace:
+			if key.HasModifier(ui.ModAlt) {
+				this.KillWordBackward()
+				killed = true
+			} else {
+				this.Backspace()
+			}
+		case ui.Delete:
+			this.Delete()
+		case ui.ArrowLeft:
+			if key.HasModifier(ui.ModCtrl | ui.ModAlt) {
+				this.move(key, this.WordLeft)
+			} else {
+				this.move(key, this.Left)
+			}
+		case ui.ArrowRight:
+			if key.HasModifier(ui.ModCtrl | ui.ModAlt) {
+				this.move(key, this.WordRight)
+			} else {
+				this.move(key, this.Right)
+			}
+		case ui.Home:
+			this.move(key, this.Home)
+		case ui.End:
+			this.move(key, this.End)
+		case ui.Paste:
+			this.InsertText(key.GetText())
+		default:
+			return false
+		}
+		return true
+	}
+
+	letter := key.GetLetter()
+	switch {
+	case key.HasModifier(ui.ModCtrl):
+		switch letter {
+		case 'a':
+			this.move(key, this.Home)
+		case 'e':
+			this.move(key, this.End)
+		case 'b':
+			this.move(key, this.Left)
+		case 'd':
+			this.Delete()
+		case 'w':
+			this.KillWordBackward()
+			killed = true
+		case 'u':
+			this.KillToStart()
+			killed = true
+		case 'k':
+			this.KillToEnd()
+			killed = true
+		case 'y':
+			this.Yank()
+			yanked = this.yanked
+		case 'z', '_':
+			this.Undo()
+		default:
+			return false
+		}
+	case key.HasModifier(ui.ModAlt):
+		switch letter {
+		case 'b':
+			this.move(key, this.WordLeft)
+		case 'f':
+			this.move(key, this.WordRight)
+		case 'd':
+			this.KillWordForward()
+			killed = true
+		case 'w':
+			this.CopySelection()
+		case 'y':
+			this.YankPop()
+			yanked = this.yanked
+		case 'z':
+			this.Redo()
+		default:
+			return false
+		}
+	default:
+		this.Insert(letter)
+	}
+	return true
+}
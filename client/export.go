@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	proto "ChitChat/grpc"
+)
+
+// exportFormat picks the transcript format from the extension of the file
+func exportFormat(path string) (proto.ExportRequest_Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".json":
+		return proto.ExportRequest_JSONL, nil
+	case ".md", ".markdown":
+		return proto.ExportRequest_MARKDOWN, nil
+	case ".html", ".htm":
+		return proto.ExportRequest_HTML, nil
+	}
+	return 0, fmt.Errorf("unknown format %q, use .jsonl, .md or .html", filepath.Ext(path))
+}
+
+// parseExportTime accepts a duration meaning that long ago, e.g. "2h", or a local date and time
+func parseExportTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use e.g. 2h or 2006-01-02T15:04", value)
+}
+
+// exportRequest builds the request for /export from options such as room=general user=bob since=1h
+func exportRequest(path string, options string) (*proto.ExportRequest, error) {
+	format, err := exportFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	req := &proto.ExportRequest{Format: format}
+
+	for _, option := range strings.Fields(options) {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid option %q, expected key=value", option)
+		}
+
+		switch key {
+		case "room":
+			req.Room = strings.TrimPrefix(value, "#")
+		case "user":
+			req.Username = value
+		case "since", "until":
+			t, err := parseExportTime(value)
+			if err != nil {
+				return nil, err
+			}
+			if key == "since" {
+				req.Since = t.Unix()
+			} else {
+				req.Until = t.Unix()
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %q, expected room, user, since or until", key)
+		}
+	}
+
+	return req, nil
+}
+
+// exportTranscript fetches the transcript and writes it to the file
+func exportTranscript(env CommandEnv, path string, options string) error {
+	req, err := exportRequest(path, options)
+	if err != nil {
+		return err
+	}
+
+	transcript, events, err := env.Client().Export(req)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, transcript, 0644); err != nil {
+		return err
+	}
+
+	env.Notice(fmt.Sprintf("exported %d events to %s", events, path))
+	return nil
+}
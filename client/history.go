@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// historySize is the number of lines kept in the input history
+const historySize = 500
+
+// InputHistory is the list of lines sent from the TUI, browsed with ArrowUp and ArrowDown
+// If it has a path every line is appended to that file, so that the history carries over to the next session
+type InputHistory struct {
+	entries  []string
+	capacity int
+	path     string
+
+	// The entry shown in the input, len(entries) while writing a new line
+	position int
+	// The line being written when browsing started, it is restored when browsing past the newest entry
+	draft string
+}
+
+// historyPath returns the file the history is kept in, which is taken from CHITCHAT_HISTORY or defaults to
+// ~/.chitchat_history. An empty CHITCHAT_HISTORY keeps the history in memory only
+func historyPath() string {
+	if path, set := os.LookupEnv("CHITCHAT_HISTORY"); set {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".chitchat_history")
+}
+
+// LoadInputHistory reads the history from the file, or starts an empty one if the path is empty
+// The file holds one JSON string per line. Errors are logged, the history then starts empty
+func LoadInputHistory(path string, capacity int) *InputHistory {
+	history := &InputHistory{capacity: capacity, path: path}
+	if path == "" {
+		return history
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read the input history: %v", err)
+		}
+		return history
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry string
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			history.entries = append(history.entries, entry)
+		}
+	}
+
+	if len(history.entries) > capacity {
+		history.entries = history.entries[len(history.entries)-capacity:]
+		history.rewrite()
+	}
+	history.position = len(history.entries)
+
+	return history
+}
+
+// rewrite replaces the file with the entries kept in memory
+func (this *InputHistory) rewrite() {
+	tmp := this.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Printf("Failed to write the input history: %v", err)
+		return
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range this.entries {
+		encoder.Encode(entry)
+	}
+	err = writer.Flush()
+	file.Close()
+
+	if err == nil {
+		err = os.Rename(tmp, this.path)
+	}
+	if err != nil {
+		log.Printf("Failed to write the input history: %v", err)
+		os.Remove(tmp)
+	}
+}
+
+// Add appends the line to the history and stops browsing, empty lines and repetitions are skipped
+func (this *InputHistory) Add(line string) {
+	defer this.Reset()
+
+	if line == "" || (len(this.entries) > 0 && this.entries[len(this.entries)-1] == line) {
+		return
+	}
+
+	this.entries = append(this.entries, line)
+	if len(this.entries) > this.capacity {
+		this.entries = this.entries[1:]
+	}
+
+	if this.path == "" {
+		return
+	}
+
+	// Lines may contain private messages, so the file is only readable by the user
+	file, err := os.OpenFile(this.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("Failed to save the input history: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(line); err != nil {
+		log.Printf("Failed to save the input history: %v", err)
+	}
+}
+
+// Reset stops browsing, the next Previous starts from the newest entry
+func (this *InputHistory) Reset() {
+	this.position = len(this.entries)
+	this.draft = ""
+}
+
+// Browsing returns true while an entry, or the line written before browsing, is shown in the input
+func (this *InputHistory) Browsing() bool {
+	return this.position < len(this.entries)
+}
+
+// Previous returns the entry before the one shown, current is the line in the input
+// Returns false if there is no older entry
+func (this *InputHistory) Previous(current string) (string, bool) {
+	if this.position == 0 {
+		return "", false
+	}
+
+	if this.position == len(this.entries) {
+		this.draft = current
+	}
+	this.position--
+	return this.entries[this.position], true
+}
+
+// Next returns the entry after the one shown, or the line which was being written before browsing
+// Returns false if not browsing the history
+func (this *InputHistory) Next() (string, bool) {
+	if this.position >= len(this.entries) {
+		return "", false
+	}
+
+	this.position++
+	if this.position == len(this.entries) {
+		return this.draft, true
+	}
+	return this.entries[this.position], true
+}
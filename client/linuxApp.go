@@ -2,9 +2,13 @@ package main
 
 import (
 	"ChitChat/ui"
-	utils "ChitChat/utils"
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/status"
 )
 
 type State uint8
@@ -13,34 +17,60 @@ const (
 	PickUsername State = iota
 	PickUsernameRejected
 	InChat
+	InThread
+	InSearch
 	Exit
 )
 
 type Application struct {
-	cursor      uint
-	inputBuffer *utils.FixedArray
+	input   *LineEditor
+	history *InputHistory
+
+	completer  *Completer
+	completion *completionState
 
 	client *Client
 	tui    *ui.UI
 
 	messages []ReceivedMessage
 	state    State
+	// Why the server refused the username, shown in the PickUsernameRejected state
+	connectError string
+
+	// The number of messages hidden below the bottom of the chat while scrolled up, and how many of them
+	// arrived since scrolling up. pageSize is the number of messages shown by the last render
+	scroll   int
+	unread   int
+	pageSize int
+
+	// The thread currently opened in the thread view
+	threadParent ReceivedMessage
+	thread       []ReceivedMessage
+
+	// The results of the last search with their context, and the terms highlighted in the messages
+	searchResults []ReceivedMessage
+	searchSummary string
+	highlight     *regexp.Regexp
 
 	keyCh chan ui.Key
 	msgCh chan ReceivedMessage
+
+	commands *CommandRegistry
 }
 
 func NewApp() *Application {
 	app := new(Application)
 	*app = Application{
-		cursor:      0,
-		inputBuffer: utils.NewFixedArray(128),
+		input:       NewLineEditor(0),
+		history:     LoadInputHistory(historyPath(), historySize),
 		client:      nil,
 		tui:         ui.NewUI(),
 		state:       PickUsername,
 		keyCh: 		 make(chan ui.Key),
 		msgCh: 		 make(chan ReceivedMessage),
+		commands:    DefaultCommands(),
 	}
+	app.completer = NewCompleter(app.commands)
 
 	app.render()
 
@@ -51,18 +81,22 @@ func NewApp() *Application {
 }
 
 func (app *Application) handleUsernameSubmit() {
-	username := app.inputBuffer.String()
+	username := app.input.String()
 
 	enableCallback := true
-	client := NewClient("localhost", "5001", username, enableCallback)
+	client, err := NewClient("localhost", "5001", username, enableCallback)
 
 	if client == nil {
 		app.state = PickUsernameRejected
+		app.connectError = status.Convert(err).Message()
 	} else {
 		app.client = client
 		app.client.SetMessageChannel(app.msgCh)
 		app.state = InChat
 
+		// The members of the room are the first users to complete
+		app.completer.Self = app.client.Username
+		app.client.Who("")
 		app.Log("Client connected to server")
 	}
 }
@@ -70,56 +104,242 @@ func (app *Application) handleUsernameSubmit() {
 func (app *Application) handleSubmit() {
 
 	switch app.state {
-	case PickUsername:
+	case PickUsername, PickUsernameRejected:
+		// A pasted username may contain line breaks
+		app.input.Set(singleLine(app.input.String()))
 		app.handleUsernameSubmit()
-	case PickUsernameRejected:
-		app.handleUsernameSubmit()
-	case InChat:
-		app.client.Send(app.inputBuffer.String())
+	case InChat, InThread:
+		// Passwords are not kept in the history file
+		if !strings.HasPrefix(app.input.String(), "/password ") {
+			app.history.Add(app.input.String())
+		}
+		app.submitChat(app.input.String())
+	case InSearch:
+		app.runSearch(singleLine(app.input.String()))
 	}
 
-	app.inputBuffer.Reset()
-	app.cursor = 0
+	app.input.Reset()
+}
+
+// singleLine joins the lines of the text with spaces
+func singleLine(text string) string {
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// submitChat runs slash commands, everything else is sent to the current room or thread
+func (app *Application) submitChat(line string) {
+	if IsCommand(line) {
+		if err := app.commands.Execute(app, line); err != nil {
+			app.Notice(err.Error())
+		}
+		return
+	}
+
+	app.scrollTo(0)
+	if app.state == InThread {
+		app.client.SendReply(app.threadParent.id, ChatText(line))
+	} else {
+		app.client.Send(ChatText(line))
+	}
+}
+
+func (app *Application) Client() *Client {
+	return app.client
+}
+
+func (app *Application) Notice(text string) {
+	app.messages = append(app.messages, ReceivedMessage{event: NoticeEvent, message: text})
+}
+
+func (app *Application) Clear() {
+	app.messages = nil
+	app.scrollTo(0)
+}
+
+// scrollTo sets the number of messages hidden below the bottom of the chat, the render clamps it to the oldest message
+func (app *Application) scrollTo(scroll int) {
+	app.scroll = max(scroll, 0)
+	app.unread = min(app.unread, app.scroll)
+}
+
+func (app *Application) Quit() {
+	app.appExit()
+}
+
+// completionState is the Tab completion in progress, repeated Tabs cycle through the candidates
+type completionState struct {
+	// Where the completed word starts in the input
+	start      uint
+	candidates []string
+	index      int
+}
+
+// complete replaces the word before the cursor with the next (step > 0) or previous completion
+func (app *Application) complete(step int) {
+	if app.completion == nil {
+		before := app.input.BeforeCursor()
+		start, candidates := app.completer.Complete(before)
+		if len(candidates) == 0 {
+			return
+		}
+
+		app.completion = &completionState{start: uint(utf8.RuneCountInString(before[:start])), candidates: candidates, index: -1}
+		if step < 0 {
+			app.completion.index = 0
+		}
+	}
+
+	c := app.completion
+	c.index = (c.index + step + len(c.candidates)) % len(c.candidates)
+	if len(c.candidates) == 1 {
+		// Nothing to cycle through, so the word is finished
+		app.input.Replace(c.start, app.input.Cursor(), c.candidates[0]+" ")
+		app.completion = nil
+		return
+	}
+	app.input.Replace(c.start, app.input.Cursor(), c.candidates[c.index])
 }
 
 func (app *Application) handleInput(key ui.Key) {
+	if key.IsSpecial() && key.GetSpecial() == ui.Resize {
+		// Everything is laid out from the size of the terminal, so a render reflows every screen
+		app.tui.UpdateSize()
+		app.render()
+		return
+	}
+
+	if key.IsSpecial() && key.GetSpecial() == ui.Tab && app.client != nil {
+		if key.HasModifier(ui.ModShift) {
+			app.complete(-1)
+		} else {
+			app.complete(1)
+		}
+		app.render()
+		return
+	}
+	app.completion = nil
+
 	if key.IsSpecial() {
 		switch key.GetSpecial() {
 		case ui.Return:
-			app.handleSubmit()
+			// Shift-Enter and Alt-Enter start a new line of a chat message
+			if (app.state == InChat || app.state == InThread) && key.HasModifier(ui.ModShift|ui.ModAlt) {
+				app.input.Insert('\n')
+			} else {
+				app.handleSubmit()
+			}
 
 		case ui.Esc:
-			app.appExit()
+			if app.state == InThread {
+				app.state = InChat
+			} else if app.state == InSearch || app.highlight != nil {
+				app.closeSearch()
+			} else {
+				app.appExit()
+			}
 
-		case ui.CtrlC:
-			app.appExit()
+		case ui.CtrlF:
+			if app.state == InChat || app.state == InThread {
+				app.state = InSearch
+				app.input.Reset()
+			} else if app.state == InSearch {
+				// Go back to the chat, but keep highlighting the hits
+				app.state = InChat
+			}
+
+		case ui.CtrlT:
+			if app.state == InChat {
+				app.openThread(app.latestThread())
+			} else if app.state == InThread {
+				app.state = InChat
+			}
+
+		case ui.ArrowUp:
+			// Move between the lines of the input first, then switch threads, scroll an empty chat or browse the history
+			if key.Modifiers() == 0 && app.input.Up() {
+				break
+			}
+			if app.state == InThread {
+				app.openThread(app.adjacentRoot(app.threadParent.id, -1))
+			} else if app.state == InChat && (key.HasModifier(ui.ModCtrl|ui.ModShift) || app.scrollsWithArrows()) {
+				app.scrollTo(app.scroll + 1)
+			} else if app.state == InChat {
+				app.previousLine()
+			}
+
+		case ui.ArrowDown:
+			if key.Modifiers() == 0 && app.input.Down() {
+				break
+			}
+			if app.state == InThread {
+				app.openThread(app.adjacentRoot(app.threadParent.id, 1))
+			} else if app.state == InChat && (key.HasModifier(ui.ModCtrl|ui.ModShift) || app.scrollsWithArrows()) {
+				app.scrollTo(app.scroll - 1)
+			} else if app.state == InChat {
+				app.nextLine()
+			}
 
-		case ui.Backspace:
-			if app.inputBuffer.Len() > 0 && app.cursor > 0 {
-				app.inputBuffer.Delete(app.cursor - 1)
-				app.cursor--
+		case ui.PageUp:
+			if app.state == InChat {
+				app.scrollTo(app.scroll + max(app.pageSize-1, 1))
 			}
 
-		case ui.ArrowLeft:
-			if app.cursor > 0 {
-				app.cursor--
+		case ui.PageDown:
+			if app.state == InChat {
+				app.scrollTo(app.scroll - max(app.pageSize-1, 1))
 			}
-		case ui.ArrowRight:
-			if app.cursor < app.inputBuffer.Len() {
-				app.cursor++
+
+		case ui.Home:
+			// Home and End move the cursor unless the input is empty
+			if app.state == InChat && app.input.Len() == 0 {
+				app.scrollTo(len(app.messages))
+			} else {
+				app.input.HandleKey(key)
 			}
+
+		case ui.End:
+			if app.state == InChat && app.input.Len() == 0 {
+				app.scrollTo(0)
+			} else {
+				app.input.HandleKey(key)
+			}
+
+		case ui.CtrlC:
+			app.appExit()
+
 		default:
-			// TODO: Handle arrow keys
+			app.input.HandleKey(key)
 		}
+	} else if app.state == InChat && key.HasModifier(ui.ModCtrl) && key.GetLetter() == 'p' {
+		app.previousLine()
+	} else if app.state == InChat && key.HasModifier(ui.ModCtrl) && key.GetLetter() == 'n' {
+		app.nextLine()
 	} else {
-		if app.inputBuffer.Len() < app.inputBuffer.Cap() {
-			app.inputBuffer.Insert(app.cursor, key.GetLetter())
-			app.cursor += 1
-		}
+		app.input.HandleKey(key)
 	}
 	app.render()
 }
 
+// scrollsWithArrows returns true if ArrowUp and ArrowDown scroll the chat, which they do while the input is empty
+// and the history is not being browsed
+func (app *Application) scrollsWithArrows() bool {
+	return app.input.Len() == 0 && !app.history.Browsing()
+}
+
+// previousLine shows the line sent before the one in the input
+func (app *Application) previousLine() {
+	if line, ok := app.history.Previous(app.input.String()); ok {
+		app.input.Set(line)
+	}
+}
+
+// nextLine shows the line sent after the one in the input, or the line which was being written before browsing
+func (app *Application) nextLine() {
+	if line, ok := app.history.Next(); ok {
+		app.input.Set(line)
+	}
+}
+
 func (app *Application) handleMessage(msg ReceivedMessage) {
 	if msg.event == ErrEvent {
 		println("Got error - exiting")
@@ -127,15 +347,176 @@ func (app *Application) handleMessage(msg ReceivedMessage) {
 		app.appExit()
 		return
 	}
-	app.messages = append(app.messages, msg)
 
+	if msg.event == KickEvent {
+		app.appExit()
+		println("You were kicked from the server: " + msg.message)
+		app.Log("Kicked from the server: " + msg.message)
+		return
+	}
+
+	switch msg.event {
+	case EditEvent:
+		app.updateMessage(msg.id, func(original *ReceivedMessage) {
+			original.message = msg.message
+			original.edited = true
+		})
+	case DeleteEvent:
+		app.updateMessage(msg.id, func(original *ReceivedMessage) {
+			original.deleted = true
+		})
+	case ReactionEvent:
+		app.updateMessage(msg.id, func(original *ReceivedMessage) {
+			original.reactions = msg.reactions
+		})
+	default:
+		if msg.event == MessageEvent && msg.parentID != 0 {
+			if app.state == InThread && app.threadParent.id == msg.parentID {
+				app.thread = append(app.thread, msg)
+			}
+			// Replies are collapsed under their parent if we have seen it
+			if parent := app.findMessage(msg.parentID); parent != nil {
+				parent.replies++
+				msg.collapsed = true
+			}
+		}
+		app.messages = append(app.messages, msg)
+
+		// Keep the view where it is while scrolled up
+		if app.scroll > 0 && !msg.collapsed {
+			app.scroll++
+			app.unread++
+		}
+	}
+
+	app.completer.Observe(&msg)
 	app.Log("Got message: " + fmt.Sprintf("%v", msg))
 
-	if app.state == InChat {
+	if app.state == InChat || app.state == InThread || app.state == InSearch {
 		app.render()
 	}
 }
 
+// findMessage returns the chat message in app.messages with the given ID
+func (app *Application) findMessage(id uint64) *ReceivedMessage {
+	for i := len(app.messages) - 1; i >= 0; i-- {
+		if app.messages[i].event == MessageEvent && app.messages[i].id == id {
+			return &app.messages[i]
+		}
+	}
+	return nil
+}
+
+// updateMessage applies the update to every copy of the chat message with the given ID
+func (app *Application) updateMessage(id uint64, update func(*ReceivedMessage)) {
+	if original := app.findMessage(id); original != nil {
+		update(original)
+	}
+
+	if app.threadParent.id == id {
+		update(&app.threadParent)
+	}
+	for i := range app.thread {
+		if app.thread[i].id == id {
+			update(&app.thread[i])
+		}
+	}
+}
+
+// latestThread returns the newest message with replies, or the newest message if nobody replied yet
+func (app *Application) latestThread() uint64 {
+	var newest uint64
+	for i := len(app.messages) - 1; i >= 0; i-- {
+		msg := &app.messages[i]
+		if msg.event != MessageEvent || msg.parentID != 0 {
+			continue
+		}
+		if msg.replies > 0 {
+			return msg.id
+		}
+		if newest == 0 {
+			newest = msg.id
+		}
+	}
+	return newest
+}
+
+// adjacentRoot returns the ID of the message which starts a thread before (direction < 0) or after the given one
+func (app *Application) adjacentRoot(id uint64, direction int) uint64 {
+	current := -1
+	for i := range app.messages {
+		if app.messages[i].event == MessageEvent && app.messages[i].id == id {
+			current = i
+			break
+		}
+	}
+	if current == -1 {
+		return id
+	}
+
+	for i := current + direction; i >= 0 && i < len(app.messages); i += direction {
+		if app.messages[i].event == MessageEvent && app.messages[i].parentID == 0 {
+			return app.messages[i].id
+		}
+	}
+	return id
+}
+
+// openThread fetches the replies of a message from the server and switches to the thread view
+func (app *Application) openThread(id uint64) {
+	if id == 0 {
+		return
+	}
+
+	parent, replies, err := app.client.Thread(id)
+	if err != nil {
+		app.Log("Failed to fetch thread: " + err.Error())
+		return
+	}
+
+	app.threadParent = parent
+	app.thread = replies
+	app.state = InThread
+}
+
+// runSearch queries the server and shows the hits with their context, the newest hit at the bottom
+func (app *Application) runSearch(query string) {
+	if strings.TrimSpace(query) == "" {
+		return
+	}
+
+	req, err := parseSearchQuery(query)
+	if err != nil {
+		app.searchSummary = err.Error()
+		return
+	}
+
+	results, total, terms, err := app.client.Search(req)
+	if err != nil {
+		app.searchSummary = "Search failed: " + err.Error()
+		app.Log("Search failed: " + err.Error())
+		return
+	}
+
+	app.searchResults = nil
+	for i := len(results) - 1; i >= 0; i-- {
+		app.searchResults = append(app.searchResults, results[i].before...)
+		app.searchResults = append(app.searchResults, results[i].hit)
+		app.searchResults = append(app.searchResults, results[i].after...)
+		app.searchResults = append(app.searchResults, ReceivedMessage{event: NoticeEvent, message: "───"})
+	}
+
+	app.searchSummary = fmt.Sprintf("%d messages match %q, showing the newest %d", total, query, len(results))
+	app.highlight = highlightPattern(terms)
+}
+
+func (app *Application) closeSearch() {
+	app.state = InChat
+	app.searchResults = nil
+	app.searchSummary = ""
+	app.highlight = nil
+}
+
 func (app *Application) eventHandler() {
 	for {
 		select {
@@ -156,50 +537,336 @@ func (app *Application) render() {
 
 	case InChat:
 		app.renderMessages()
+	case InThread:
+		app.renderThread()
+	case InSearch:
+		app.renderSearch()
 	}
 
 	app.tui.Render()
 }
 
+// sidebarWidth is the width of the sidebar, which is only shown if the terminal is at least minSidebarScreen wide
+const (
+	sidebarWidth     = 22
+	minSidebarScreen = 70
+)
+
+// maxInputRows is the most rows the input grows to, longer input scrolls to keep the cursor visible
+const maxInputRows = 5
+
+// chatScreen lays out the screens shown while connected: the status bar on top, the pane next to the sidebar, and
+// the input bar at the bottom. The footer, if any, goes between the pane and the input
+func (app *Application) chatScreen(title string, pane ui.Widget, footer ui.Widget, prompt string) ui.Widget {
+	width := int(app.tui.GetUIWidth())
+
+	body := pane
+	if width >= minSidebarScreen {
+		body = ui.NewSplit(ui.Horizontal,
+			ui.SplitItem{Widget: pane},
+			ui.SplitItem{Widget: app.sidebar(), Size: sidebarWidth},
+		)
+	}
+
+	input := &ui.TextInput{
+		Prompt:      prompt,
+		PromptColor: ui.Blue,
+		Text:        app.input,
+		// Never take more than a third of the screen, so that some messages stay visible
+		MaxRows: max(min(maxInputRows, int(app.tui.GetUIHeight())/3), 1),
+	}
+
+	items := []ui.SplitItem{
+		{Widget: &ui.StatusBar{Left: title, Right: app.client.Username() + " ", Fg: ui.White, Bg: ui.Blue}, Size: 1},
+		{Widget: body},
+	}
+	if footer != nil {
+		items = append(items, ui.SplitItem{Widget: footer, Size: 1})
+	}
+	if app.completion != nil {
+		items = append(items, ui.SplitItem{Widget: ui.DrawFunc(app.drawCompletions), Size: 1})
+	}
+	items = append(items, ui.SplitItem{Widget: input, Size: input.Height(width)})
+
+	return ui.NewSplit(ui.Vertical, items...)
+}
+
+// sidebar lists the users and the rooms seen in the chat, our own name and the current room stand out
+func (app *Application) sidebar() ui.Widget {
+	users := &ui.List{}
+	for _, username := range app.completer.Users() {
+		label := &ui.Label{Text: username, Fg: ui.Default}
+		if username == app.client.Username() {
+			label.Fg, label.Style = ui.Blue, ui.Bold
+		}
+		users.Items = append(users.Items, label)
+	}
+
+	rooms := &ui.List{}
+	for _, room := range app.completer.Rooms() {
+		label := &ui.Label{Text: "#" + room, Fg: ui.Default}
+		if room == app.client.Room() {
+			label.Fg, label.Style = ui.Blue, ui.Bold
+		}
+		rooms.Items = append(rooms.Items, label)
+	}
+
+	return ui.NewSplit(ui.Vertical,
+		ui.SplitItem{Widget: &ui.Box{Title: "Users", Color: ui.Cyan, Child: users}},
+		ui.SplitItem{Widget: &ui.Box{Title: "Rooms", Color: ui.Cyan, Child: rooms}},
+	)
+}
+
+// messageItem shows a message in a list, indented by the given number of cells
+type messageItem struct {
+	app    *Application
+	msg    *ReceivedMessage
+	indent int
+}
+
+func (item messageItem) Height(width int) int {
+	return messageRows(item.msg, width-item.indent)
+}
+
+func (item messageItem) Draw(c *ui.Canvas) {
+	item.app.drawMessage(c, item.msg, item.indent)
+}
+
+// messageList returns a list of the messages which are not collapsed
+func (app *Application) messageList(messages []ReceivedMessage, indent int) *ui.List {
+	list := &ui.List{}
+	for i := range messages {
+		if !messages[i].collapsed {
+			list.Items = append(list.Items, messageItem{app: app, msg: &messages[i], indent: indent})
+		}
+	}
+	return list
+}
+
 func (app *Application) renderMessages() {
-	app.tui.SetCursor(0, 0)
-	app.tui.Write("Connected to ChitChat", ui.Red, ui.Default, ui.Underlined)
+	list := app.messageList(app.messages, 0)
+	list.Scroll = app.scroll
+
+	var footer ui.Widget
+	if app.scroll > 0 {
+		footer = ui.DrawFunc(func(c *ui.Canvas) {
+			// The list stops scrolling at the oldest message, so fewer may be hidden than asked for
+			if unread := min(app.unread, list.Hidden()); unread > 0 {
+				c.Write(ui.Truncate(fmt.Sprintf("── %d new messages below, End to jump back ──", unread), c.Width()), ui.Black, ui.Yellow, ui.Normal)
+			} else {
+				c.Write(ui.Truncate(fmt.Sprintf("── %d more messages below, End to jump back ──", list.Hidden()), c.Width()), ui.Yellow, ui.Default, ui.Normal)
+			}
+		})
+	}
+
+	pane := &ui.Box{Title: "#" + app.client.Room(), Color: ui.Red, Child: list}
+	app.tui.Draw(app.chatScreen("ChitChat", pane, footer, "> "))
+
+	app.scrollTo(list.Hidden())
+	app.pageSize = list.Shown()
+}
+
+func (app *Application) renderThread() {
+	parent := messageItem{app: app, msg: &app.threadParent}
+	thread := ui.NewSplit(ui.Vertical,
+		ui.SplitItem{Widget: &ui.List{Items: []ui.ListItem{parent}}, Size: parent.Height(app.paneWidth())},
+		ui.SplitItem{Widget: app.messageList(app.thread, 4)},
+	)
+
+	pane := &ui.Box{Title: fmt.Sprintf("Thread #%d", app.threadParent.id), Color: ui.Red, Child: thread}
+	app.tui.Draw(app.chatScreen("Thread - Esc to go back, Up/Down to switch thread", pane, nil, "reply> "))
+}
+
+func (app *Application) renderSearch() {
+	results := ui.NewSplit(ui.Vertical,
+		ui.SplitItem{Widget: &ui.Label{Text: app.searchSummary, Fg: ui.Yellow}, Size: 1},
+		ui.SplitItem{Widget: app.messageList(app.searchResults, 0)},
+	)
+
+	pane := &ui.Box{Title: "Search", Color: ui.Red, Child: results}
+	app.tui.Draw(app.chatScreen("Search - Enter to search, Ctrl-F to go back highlighting the hits, Esc to close", pane, nil, "search> "))
+}
+
+// paneWidth returns the width of the messages inside the border of the pane
+func (app *Application) paneWidth() int {
+	width := int(app.tui.GetUIWidth())
+	if width >= minSidebarScreen {
+		width -= sidebarWidth
+	}
+	return width - 2
+}
+
+// writeHighlighted writes the text, showing the hits of the current search reversed
+func (app *Application) writeHighlighted(c *ui.Canvas, text string, style ui.Style) {
+	if app.highlight == nil {
+		c.Write(text, ui.Default, ui.Default, style)
+		return
+	}
+
+	last := 0
+	for _, match := range app.highlight.FindAllStringIndex(text, -1) {
+		c.Write(text[last:match[0]], ui.Default, ui.Default, style)
+		c.Write(text[match[0]:match[1]], ui.Yellow, ui.Default, ui.Reversed)
+		last = match[1]
+	}
+	c.Write(text[last:], ui.Default, ui.Default, style)
+}
+
+// drawCompletions lists the candidates of the completion in progress, the current one reversed
+func (app *Application) drawCompletions(c *ui.Canvas) {
+	c.Move(0, 2)
+	width := c.Width() - 2
+	for i, candidate := range app.completion.candidates {
+		if ui.StringWidth(candidate)+2 > width {
+			c.Write(ui.Ellipsis, ui.Cyan, ui.Default, ui.Normal)
+			return
+		}
+
+		style := ui.Normal
+		if i == app.completion.index {
+			style = ui.Reversed
+		}
+		c.Write(candidate, ui.Cyan, ui.Default, style)
+		c.Write("  ", ui.Default, ui.Default, ui.Normal)
+		width -= ui.StringWidth(candidate) + 2
+	}
+}
+
+// cursorColumn returns the number of cells taken by the input before the cursor
+func (app *Application) cursorColumn() uint {
+	return uint(ui.StringWidth(app.input.BeforeCursor()))
+}
+
+// wrapIndent is how far the continuation rows of a wrapped message are indented
+const wrapIndent = 2
+
+// editedMarker is appended to the text of edited messages, so that it is wrapped along with it
+const editedMarker = "(edited)"
+
+// messageParts splits a message into the header, which is drawn in its own style, and the text following it
+func messageParts(msg *ReceivedMessage) (string, string) {
+	switch msg.event {
+	case LoginEvent:
+		return "", fmt.Sprintf("%s @ %d connected to the chat", msg.author, msg.lamportTimestamp)
+	case LogoutEvent:
+		return "", fmt.Sprintf("%s @ %d disconnected from the chat", msg.author, msg.lamportTimestamp)
+	case MessageEvent:
+		header := fmt.Sprintf("%s @ %d #%d: ", msg.author, msg.lamportTimestamp, msg.id)
+		if msg.parentID != 0 {
+			header = fmt.Sprintf("%s @ %d #%d (reply to #%d): ", msg.author, msg.lamportTimestamp, msg.id, msg.parentID)
+		}
+		if msg.emote {
+			header = fmt.Sprintf("* %s @ %d #%d ", msg.author, msg.lamportTimestamp, msg.id)
+		}
+
+		text := msg.message
+		if msg.edited {
+			text += " " + editedMarker
+		}
+		return header, text
+	case NickEvent:
+		return "", fmt.Sprintf("%s @ %d changed name to %s", msg.author, msg.lamportTimestamp, msg.target)
+	case JoinEvent:
+		return "", fmt.Sprintf("%s @ %d joined #%s", msg.author, msg.lamportTimestamp, msg.room)
+	case PrivateEvent:
+		return "", fmt.Sprintf("%s -> %s @ %d: %s", msg.author, msg.target, msg.lamportTimestamp, msg.message)
+	case MembersEvent:
+		return "", fmt.Sprintf("Users in #%s: %s", msg.room, strings.Join(msg.members, ", "))
+	case RejectedEvent:
+		return "", "Rejected: " + msg.message
+	case SystemEvent:
+		return "", fmt.Sprintf("[server] @ %d: %s", msg.lamportTimestamp, msg.message)
+	case NoticeEvent:
+		return "", msg.message
+	}
+	return "", ""
+}
+
+// messageLines returns the header of the message and its text wrapped to the width
+// The first line follows the header, the others are indented by wrapIndent
+func messageLines(msg *ReceivedMessage, width int) (string, []string) {
+	header, text := messageParts(msg)
+	return header, ui.Wrap(text, width-ui.StringWidth(header), width-wrapIndent)
+}
 
-	n := len(app.messages)
+// messageRows returns the number of rows a message occupies on screen when it is wrapped to the width
+func messageRows(msg *ReceivedMessage, width int) int {
+	if msg.collapsed {
+		return 0
+	}
 
-	totalSpace := int(app.tui.GetUIHeight()) - 2
+	_, lines := messageLines(msg, width)
+	rows := len(lines)
+	if len(msg.reactions) > 0 {
+		rows++
+	}
+	if msg.replies > 0 {
+		rows++
+	}
+	return rows
+}
 
-	msg := max(n - totalSpace-1, 0)
+// drawMessage draws the message from the top of the canvas, indented by the given number of cells
+func (app *Application) drawMessage(c *ui.Canvas, msg *ReceivedMessage, column int) {
+	width := c.Width() - column
+	header, lines := messageLines(msg, width)
 
-	for r := 0; r < totalSpace && msg < n; r++ {
-		app.tui.SetCursor(uint(r+1), 2)
+	c.Move(0, column)
 
-		var col ui.Color
-		if app.messages[msg].author == "You" {
+	fg, bg, style := ui.Default, ui.Default, ui.Normal
+	switch msg.event {
+	case MessageEvent:
+		col := ui.Red
+		if msg.author == "You" {
 			col = ui.Blue
-		} else {
-			col = ui.Red
 		}
+		c.Write(header, ui.Default, col, ui.Italic)
+
+		if msg.emote {
+			style = ui.Italic
+		}
+		if msg.deleted {
+			style = ui.Striketrhough
+		}
+	case PrivateEvent:
+		fg = ui.Magenta
+	case MembersEvent, NoticeEvent:
+		fg = ui.Yellow
+	case RejectedEvent:
+		fg, bg = ui.White, ui.Red
+	case SystemEvent:
+		fg, style = ui.Cyan, ui.Bold
+	}
 
-		switch app.messages[msg].event {
-		case LoginEvent:
-			app.tui.Write(fmt.Sprintf("%s @ %d connected to the chat", app.messages[msg].author, app.messages[msg].lamportTimestamp), ui.Default, ui.Default, ui.Normal)
-		case LogoutEvent:
-			app.tui.Write(fmt.Sprintf("%s @ %d disconnected from the chat", app.messages[msg].author, app.messages[msg].lamportTimestamp), ui.Default, ui.Default, ui.Normal)
-		case MessageEvent:
-			app.tui.Write(fmt.Sprintf("%s @ %d: ", app.messages[msg].author, app.messages[msg].lamportTimestamp), ui.Default, col, ui.Italic)
-			app.tui.Write(app.messages[msg].message,
-				ui.Default, ui.Default, ui.Normal)
+	row := 0
+	for i, line := range lines {
+		if i > 0 {
+			row++
+			c.Move(row, column+wrapIndent)
+		}
+
+		switch {
+		case msg.event != MessageEvent:
+			c.Write(line, fg, bg, style)
+		case msg.edited && i == len(lines)-1:
+			app.writeHighlighted(c, strings.TrimSuffix(line, editedMarker), style)
+			c.Write(editedMarker, ui.Default, ui.Default, ui.Italic)
+		default:
+			app.writeHighlighted(c, line, style)
 		}
-		msg++
 	}
+	row++
 
-	cursorRow := uint(msg+1)
-	app.tui.SetCursor(cursorRow, 0)
-	app.tui.Write("> ", ui.Blue, ui.Default, ui.Bold)
-	app.tui.Write(app.inputBuffer.String(), ui.Default, ui.Default, ui.Normal)
+	if len(msg.reactions) > 0 {
+		c.Move(row, column+2)
+		c.Write(ui.Truncate(msg.ReactionSummary(), width-2), ui.Yellow, ui.Default, ui.Normal)
+		row++
+	}
 
-	app.tui.SetCursor(cursorRow, app.cursor+2)
+	if msg.replies > 0 {
+		c.Move(row, column+2)
+		c.Write(ui.Truncate(fmt.Sprintf("└ %d replies (Ctrl-T to open)", msg.replies), width-2), ui.Cyan, ui.Default, ui.Normal)
+	}
 }
 
 func (app *Application) renderStartMenu() {
@@ -211,15 +878,15 @@ func (app *Application) renderStartMenu() {
 
 	if app.state == PickUsernameRejected {
 		app.tui.SetCursor(halfHeight, halfWidth)
-		app.tui.WriteCentered("That username is already taken", ui.White, ui.Red, ui.Normal)
+		app.tui.WriteCentered(app.connectError, ui.White, ui.Red, ui.Normal)
 	}
 
-	str := app.inputBuffer.String()
+	str := app.input.String()
 
-	inputStartColumn := halfWidth - uint(len(str)/2)
+	inputStartColumn := halfWidth - min(uint(ui.StringWidth(str)/2), halfWidth)
 	app.tui.SetCursor(halfHeight+1, inputStartColumn)
 	app.tui.Write(str, ui.Default, ui.Default, ui.Normal)
-	app.tui.SetCursor(halfHeight+1, inputStartColumn+app.cursor)
+	app.tui.SetCursor(halfHeight+1, inputStartColumn+app.cursorColumn())
 }
 
 func (app *Application) appExit() {
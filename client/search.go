@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	proto "ChitChat/grpc"
+	clocks "ChitChat/logical_clocks"
+)
+
+// searchContext is the number of messages shown around every search hit
+const searchContext = 2
+
+type SearchResult struct {
+	hit    ReceivedMessage
+	before []ReceivedMessage
+	after  []ReceivedMessage
+}
+
+// Search runs the query on the server and returns the hits, the total number of matches and the matched terms
+func (this *Client) Search(req *proto.SearchRequest) ([]SearchResult, uint32, []string, error) {
+	this.clock.Tick()
+	req.Timestamp = this.clock.Now()
+
+	resp, err := this.client.Search(this.ctx, req)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	this.clock.Sync(clocks.From(resp.Timestamp))
+
+	results := make([]SearchResult, 0, len(resp.Hits))
+	for _, hit := range resp.Hits {
+		result := SearchResult{hit: this.chatMessage(hit.Message)}
+		result.hit.lamportTimestamp = hit.Lamport
+		for _, msg := range hit.Before {
+			result.before = append(result.before, this.chatMessage(msg))
+		}
+		for _, msg := range hit.After {
+			result.after = append(result.after, this.chatMessage(msg))
+		}
+		results = append(results, result)
+	}
+
+	return results, resp.Total, resp.Terms, nil
+}
+
+// parseSearchQuery turns a query such as "deploy from:bob in:dev since:2h" into a request
+// Words without a prefix are keywords which must all occur in the message
+func parseSearchQuery(query string) (*proto.SearchRequest, error) {
+	req := &proto.SearchRequest{Context: searchContext}
+	keywords := []string{}
+
+	for _, word := range strings.Fields(query) {
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			keywords = append(keywords, word)
+			continue
+		}
+
+		switch key {
+		case "from":
+			req.Username = value
+		case "in":
+			req.Room = strings.TrimPrefix(value, "#")
+		case "since", "until":
+			t, err := parseExportTime(value)
+			if err != nil {
+				return nil, err
+			}
+			if key == "since" {
+				req.Since = t.Unix()
+			} else {
+				req.Until = t.Unix()
+			}
+		case "after", "before":
+			timestamp, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Lamport timestamp: %s", value)
+			}
+			if key == "after" {
+				req.FromTimestamp = timestamp
+			} else {
+				req.ToTimestamp = timestamp
+			}
+		default:
+			keywords = append(keywords, word)
+		}
+	}
+
+	req.Query = strings.Join(keywords, " ")
+	return req, nil
+}
+
+// highlightPattern matches the search terms anywhere in a text, ignoring case
+// Returns nil if there is nothing to highlight
+func highlightPattern(terms []string) *regexp.Regexp {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// searchNotices prints the results of /search as notices
+func searchNotices(env CommandEnv, query string) error {
+	req, err := parseSearchQuery(query)
+	if err != nil {
+		return err
+	}
+
+	results, total, _, err := env.Client().Search(req)
+	if err != nil {
+		return err
+	}
+
+	env.Notice(fmt.Sprintf("%d messages match %q, showing the newest %d", total, query, len(results)))
+	for _, result := range results {
+		hit := &result.hit
+		env.Notice(fmt.Sprintf("  #%s %s @ %d #%d: %s", hit.room, hit.author, hit.lamportTimestamp, hit.id, hit.message))
+	}
+	return nil
+}
@@ -6,12 +6,20 @@ import (
 	"fmt"
 	"bufio"
 	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/status"
 )
 
 func inputReader(ch chan string) {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		str, _ := reader.ReadString('\n')
+		// A line ending in a tab asks for completions, so the tab is kept
+		if line := strings.TrimRight(str, "\r\n"); strings.HasSuffix(line, "\t") {
+			ch <- strings.TrimLeft(line, " ")
+			continue
+		}
 		ch <- strings.TrimSpace(str)
 	}
 }
@@ -26,14 +34,62 @@ func msgReceiver(client *Client, ch chan ReceivedMessage) {
 	}
 }
 
+// printable removes control characters, which includes the escape character starting terminal escape sequences,
+// and the invisible formatting characters such as bidi overrides. Line breaks are kept
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && (unicode.IsControl(r) || unicode.Is(unicode.Cf, r)) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// printf prints like fmt.Printf, but the strings are made printable first, since they come from the server
+func printf(format string, args ...any) {
+	for i, arg := range args {
+		if text, ok := arg.(string); ok {
+			args[i] = printable(text)
+		}
+	}
+	fmt.Printf(format, args...)
+}
+
 func handleMessage(msg *ReceivedMessage) bool {
 	switch msg.event {
 	case MessageEvent:
-		fmt.Printf("%s @ %d: %s\n", msg.author, msg.lamportTimestamp, msg.message)
+		if msg.emote {
+			printf("* %s @ %d #%d %s\n", msg.author, msg.lamportTimestamp, msg.id, msg.message)
+		} else if msg.parentID != 0 {
+			printf("%s @ %d #%d (reply to #%d): %s\n", msg.author, msg.lamportTimestamp, msg.id, msg.parentID, msg.message)
+		} else {
+			printf("%s @ %d #%d: %s\n", msg.author, msg.lamportTimestamp, msg.id, msg.message)
+		}
+	case EditEvent:
+		printf("%s @ %d: edited #%d: %s\n", msg.author, msg.lamportTimestamp, msg.id, msg.message)
+	case DeleteEvent:
+		printf("%s @ %d: deleted #%d\n", msg.author, msg.lamportTimestamp, msg.id)
+	case NickEvent:
+		printf("%s @ %d: changed name to %s\n", msg.author, msg.lamportTimestamp, msg.target)
+	case JoinEvent:
+		printf("%s @ %d: joined #%s\n", msg.author, msg.lamportTimestamp, msg.room)
+	case PrivateEvent:
+		printf("%s -> %s @ %d: %s\n", msg.author, msg.target, msg.lamportTimestamp, msg.message)
+	case MembersEvent:
+		printf("Users in #%s: %s\n", msg.room, strings.Join(msg.members, ", "))
+	case RejectedEvent:
+		printf("Rejected: %s\n", msg.message)
+	case SystemEvent:
+		printf("[server] @ %d: %s\n", msg.lamportTimestamp, msg.message)
+	case KickEvent:
+		printf("You were kicked from the server: %s\n", msg.message)
+		return false
+	case ReactionEvent:
+		printf("%s @ %d: reacted to #%d, reactions are now: %s\n", msg.author, msg.lamportTimestamp, msg.id, msg.ReactionSummary())
 	case LoginEvent:
-		fmt.Printf("%s @ %d: connected to the chat\n", msg.author, msg.lamportTimestamp)
+		printf("%s @ %d: connected to the chat\n", msg.author, msg.lamportTimestamp)
 	case LogoutEvent:
-		fmt.Printf("%s @ %d: disconnected from the chat\n", msg.author, msg.lamportTimestamp)
+		printf("%s @ %d: disconnected from the chat\n", msg.author, msg.lamportTimestamp)
 	case ErrEvent:
 		println("Got error")
 		return false
@@ -42,6 +98,28 @@ func handleMessage(msg *ReceivedMessage) bool {
 	return true
 }
 
+// simpleEnv lets slash commands interact with the plain front-end
+type simpleEnv struct {
+	client  *Client
+	running bool
+}
+
+func (env *simpleEnv) Client() *Client {
+	return env.client
+}
+
+func (env *simpleEnv) Notice(text string) {
+	fmt.Println(printable(text))
+}
+
+func (env *simpleEnv) Clear() {
+	fmt.Print("\033[H\033[2J")
+}
+
+func (env *simpleEnv) Quit() {
+	env.running = false
+}
+
 func Log(message string, client *Client) {
 	log.Printf("logical timestamp=\"%v\", component=\"client\", type=\"%v\", username=\"%v\"",
 		client.clock.Now(), message, client.Username())
@@ -58,10 +136,11 @@ func Windows() {
 	for {
 		println("Pick username:");
 		enableCallback := false
-		client = NewClient("localhost", "5001", <-inputCh, enableCallback)
+		var err error
+		client, err = NewClient("localhost", "5001", <-inputCh, enableCallback)
 
-		if client == nil {
-			println("That username is already in use")
+		if err != nil {
+			println(printable(status.Convert(err).Message()))
 		} else {
 			break
 		}
@@ -71,18 +150,35 @@ func Windows() {
 	println("You are now connected to the esrver")
 	Log("Connected to server", client)
 
-	var running = true
-	for running {
+	env := &simpleEnv{client: client, running: true}
+	commands := DefaultCommands()
+	completer := NewCompleter(commands)
+	completer.Self = client.Username
+	client.Who("")
+
+	for env.running {
 		select {
 		case input := <- inputCh:
-		if client.Send(input) != nil {
+		if strings.HasSuffix(input, "\t") {
+			completionNotice(env, completer, strings.TrimSuffix(input, "\t"))
+			continue
+		}
+		if IsCommand(input) {
+			if err := commands.Execute(env, input); err != nil {
+				env.Notice(err.Error())
+			}
+			Log("Ran command: " + input, client)
+			continue
+		}
+		if client.Send(ChatText(input)) != nil {
 			println("Failed to send message")
-			running = false 
+			env.running = false
 			Log("Failed to send message: " + input, client)
 		}
 			Log("Sent message: " + input, client)
 		case msg := <- msgCh:
-			running = handleMessage(&msg)
+			completer.Observe(&msg)
+			env.running = handleMessage(&msg)
 			Log("Got message: " + msg.message, client)
 		}
 	}
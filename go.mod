@@ -2,12 +2,15 @@ module ChitChat
 
 go 1.25.1
 
+require (
+	golang.org/x/term v0.36.0 // direct
+	golang.org/x/text v0.27.0 // direct
+	google.golang.org/grpc v1.76.0
+	google.golang.org/protobuf v1.36.6
+)
+
 require (
 	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	golang.org/x/term v0.36.0 // direct
-	golang.org/x/text v0.27.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
-	google.golang.org/grpc v1.76.0 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
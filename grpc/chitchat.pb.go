@@ -21,29 +21,2351 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Role int32
+
+const (
+	Role_MEMBER    Role = 0
+	Role_MODERATOR Role = 1
+	Role_OWNER     Role = 2
+)
+
+// Enum value maps for Role.
+var (
+	Role_name = map[int32]string{
+		0: "MEMBER",
+		1: "MODERATOR",
+		2: "OWNER",
+	}
+	Role_value = map[string]int32{
+		"MEMBER":    0,
+		"MODERATOR": 1,
+		"OWNER":     2,
+	}
+)
+
+func (x Role) Enum() *Role {
+	p := new(Role)
+	*p = x
+	return p
+}
+
+func (x Role) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Role) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chitchat_proto_enumTypes[0].Descriptor()
+}
+
+func (Role) Type() protoreflect.EnumType {
+	return &file_proto_chitchat_proto_enumTypes[0]
+}
+
+func (x Role) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Role.Descriptor instead.
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{0}
+}
+
+type ExportRequest_Format int32
+
+const (
+	ExportRequest_JSONL    ExportRequest_Format = 0
+	ExportRequest_MARKDOWN ExportRequest_Format = 1
+	ExportRequest_HTML     ExportRequest_Format = 2
+)
+
+// Enum value maps for ExportRequest_Format.
+var (
+	ExportRequest_Format_name = map[int32]string{
+		0: "JSONL",
+		1: "MARKDOWN",
+		2: "HTML",
+	}
+	ExportRequest_Format_value = map[string]int32{
+		"JSONL":    0,
+		"MARKDOWN": 1,
+		"HTML":     2,
+	}
+)
+
+func (x ExportRequest_Format) Enum() *ExportRequest_Format {
+	p := new(ExportRequest_Format)
+	*p = x
+	return p
+}
+
+func (x ExportRequest_Format) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExportRequest_Format) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chitchat_proto_enumTypes[1].Descriptor()
+}
+
+func (ExportRequest_Format) Type() protoreflect.EnumType {
+	return &file_proto_chitchat_proto_enumTypes[1]
+}
+
+func (x ExportRequest_Format) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExportRequest_Format.Descriptor instead.
+func (ExportRequest_Format) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type StreamResponse_Error_Code int32
+
+const (
+	StreamResponse_Error_UNKNOWN           StreamResponse_Error_Code = 0
+	StreamResponse_Error_INVALID_ARGUMENT  StreamResponse_Error_Code = 1
+	StreamResponse_Error_NOT_FOUND         StreamResponse_Error_Code = 2
+	StreamResponse_Error_ALREADY_EXISTS    StreamResponse_Error_Code = 3
+	StreamResponse_Error_PERMISSION_DENIED StreamResponse_Error_Code = 4
+	StreamResponse_Error_MUTED             StreamResponse_Error_Code = 5
+	StreamResponse_Error_THROTTLED         StreamResponse_Error_Code = 6
+	StreamResponse_Error_MESSAGE_TOO_LONG  StreamResponse_Error_Code = 7
+	StreamResponse_Error_MESSAGE_TOO_SHORT StreamResponse_Error_Code = 8
+	StreamResponse_Error_INVALID_ENCODING  StreamResponse_Error_Code = 9
+	StreamResponse_Error_FILTERED          StreamResponse_Error_Code = 10
+)
+
+// Enum value maps for StreamResponse_Error_Code.
+var (
+	StreamResponse_Error_Code_name = map[int32]string{
+		0:  "UNKNOWN",
+		1:  "INVALID_ARGUMENT",
+		2:  "NOT_FOUND",
+		3:  "ALREADY_EXISTS",
+		4:  "PERMISSION_DENIED",
+		5:  "MUTED",
+		6:  "THROTTLED",
+		7:  "MESSAGE_TOO_LONG",
+		8:  "MESSAGE_TOO_SHORT",
+		9:  "INVALID_ENCODING",
+		10: "FILTERED",
+	}
+	StreamResponse_Error_Code_value = map[string]int32{
+		"UNKNOWN":           0,
+		"INVALID_ARGUMENT":  1,
+		"NOT_FOUND":         2,
+		"ALREADY_EXISTS":    3,
+		"PERMISSION_DENIED": 4,
+		"MUTED":             5,
+		"THROTTLED":         6,
+		"MESSAGE_TOO_LONG":  7,
+		"MESSAGE_TOO_SHORT": 8,
+		"INVALID_ENCODING":  9,
+		"FILTERED":          10,
+	}
+)
+
+func (x StreamResponse_Error_Code) Enum() *StreamResponse_Error_Code {
+	p := new(StreamResponse_Error_Code)
+	*p = x
+	return p
+}
+
+func (x StreamResponse_Error_Code) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StreamResponse_Error_Code) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_chitchat_proto_enumTypes[2].Descriptor()
+}
+
+func (StreamResponse_Error_Code) Type() protoreflect.EnumType {
+	return &file_proto_chitchat_proto_enumTypes[2]
+}
+
+func (x StreamResponse_Error_Code) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StreamResponse_Error_Code.Descriptor instead.
+func (StreamResponse_Error_Code) EnumDescriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 7, 0}
+}
+
 type ConnectRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Username  string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	// Required for usernames which have a password, which every username holding a role has
+	Password      string `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectRequest) Reset() {
+	*x = ConnectRequest{}
+	mi := &file_proto_chitchat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectRequest) ProtoMessage() {}
+
+func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
+func (*ConnectRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ConnectRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ConnectRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ConnectRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type ConnectResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Token     string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	// The room the client starts out in
+	Room          string `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectResponse) Reset() {
+	*x = ConnectResponse{}
+	mi := &file_proto_chitchat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectResponse) ProtoMessage() {}
+
+func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
+func (*ConnectResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ConnectResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ConnectResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ConnectResponse) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type ThreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Id            uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadRequest) Reset() {
+	*x = ThreadRequest{}
+	mi := &file_proto_chitchat_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadRequest) ProtoMessage() {}
+
+func (x *ThreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadRequest.ProtoReflect.Descriptor instead.
+func (*ThreadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ThreadRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ThreadRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ThreadResponse struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Timestamp     uint64                    `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Parent        *StreamResponse_Message   `protobuf:"bytes,2,opt,name=parent,proto3" json:"parent,omitempty"`
+	Replies       []*StreamResponse_Message `protobuf:"bytes,3,rep,name=replies,proto3" json:"replies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadResponse) Reset() {
+	*x = ThreadResponse{}
+	mi := &file_proto_chitchat_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadResponse) ProtoMessage() {}
+
+func (x *ThreadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadResponse.ProtoReflect.Descriptor instead.
+func (*ThreadResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ThreadResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ThreadResponse) GetParent() *StreamResponse_Message {
+	if x != nil {
+		return x.Parent
+	}
+	return nil
+}
+
+func (x *ThreadResponse) GetReplies() []*StreamResponse_Message {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Format    ExportRequest_Format   `protobuf:"varint,2,opt,name=format,proto3,enum=proto.ExportRequest_Format" json:"format,omitempty"`
+	// Unix times in seconds, 0 leaves the range open
+	Since int64 `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`
+	Until int64 `protobuf:"varint,4,opt,name=until,proto3" json:"until,omitempty"`
+	// Only export events of this room, events which concern every room such as logins are always included
+	Room string `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
+	// Only export events of this user
+	Username      string `protobuf:"bytes,6,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_proto_chitchat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExportRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetFormat() ExportRequest_Format {
+	if x != nil {
+		return x.Format
+	}
+	return ExportRequest_JSONL
+}
+
+func (x *ExportRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type ExportResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp  uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transcript []byte                 `protobuf:"bytes,2,opt,name=transcript,proto3" json:"transcript,omitempty"`
+	// The number of events in the transcript
+	Events        uint32 `protobuf:"varint,3,opt,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	mi := &file_proto_chitchat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ExportResponse) GetTranscript() []byte {
+	if x != nil {
+		return x.Transcript
+	}
+	return nil
+}
+
+func (x *ExportResponse) GetEvents() uint32 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+type SearchRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Keywords which must all occur in a message, ignoring case
+	Query    string `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	Username string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Room     string `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	// Range of Lamport timestamps, 0 leaves the range open
+	FromTimestamp uint64 `protobuf:"varint,5,opt,name=from_timestamp,json=fromTimestamp,proto3" json:"from_timestamp,omitempty"`
+	ToTimestamp   uint64 `protobuf:"varint,6,opt,name=to_timestamp,json=toTimestamp,proto3" json:"to_timestamp,omitempty"`
+	// Unix times in seconds, 0 leaves the range open
+	Since int64 `protobuf:"varint,7,opt,name=since,proto3" json:"since,omitempty"`
+	Until int64 `protobuf:"varint,8,opt,name=until,proto3" json:"until,omitempty"`
+	// The maximum number of hits, the newest messages come first
+	Limit uint32 `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The number of messages of the same room to include before and after every hit
+	Context       uint32 `protobuf:"varint,10,opt,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_proto_chitchat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetFromTimestamp() uint64 {
+	if x != nil {
+		return x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetToTimestamp() uint64 {
+	if x != nil {
+		return x.ToTimestamp
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetUntil() int64 {
+	if x != nil {
+		return x.Until
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetContext() uint32 {
+	if x != nil {
+		return x.Context
+	}
+	return 0
+}
+
+type SearchHit struct {
+	state   protoimpl.MessageState  `protogen:"open.v1"`
+	Message *StreamResponse_Message `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Lamport uint64                  `protobuf:"varint,2,opt,name=lamport,proto3" json:"lamport,omitempty"`
+	// Unix time in seconds
+	Time          int64                     `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Before        []*StreamResponse_Message `protobuf:"bytes,4,rep,name=before,proto3" json:"before,omitempty"`
+	After         []*StreamResponse_Message `protobuf:"bytes,5,rep,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_proto_chitchat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchHit) GetMessage() *StreamResponse_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *SearchHit) GetLamport() uint64 {
+	if x != nil {
+		return x.Lamport
+	}
+	return 0
+}
+
+func (x *SearchHit) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *SearchHit) GetBefore() []*StreamResponse_Message {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *SearchHit) GetAfter() []*StreamResponse_Message {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Hits      []*SearchHit           `protobuf:"bytes,2,rep,name=hits,proto3" json:"hits,omitempty"`
+	// The number of matching messages, which may be more than the number of hits
+	Total uint32 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	// The keywords as they were matched, so that clients can highlight them
+	Terms         []string `protobuf:"bytes,4,rep,name=terms,proto3" json:"terms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_proto_chitchat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetTotal() uint32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetTerms() []string {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
+type StreamRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Token     string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Message   string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Optional ID of the message a chat message replies to
+	ParentId uint64 `protobuf:"varint,7,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	// Marks a chat message as an action, e.g. "/me waves"
+	Emote bool `protobuf:"varint,8,opt,name=emote,proto3" json:"emote,omitempty"`
+	// A request without an action is a plain chat message
+	//
+	// Types that are valid to be assigned to Action:
+	//
+	//	*StreamRequest_EditRequest
+	//	*StreamRequest_DeleteRequest
+	//	*StreamRequest_ReactRequest
+	//	*StreamRequest_NickRequest
+	//	*StreamRequest_PrivateRequest
+	//	*StreamRequest_JoinRequest
+	//	*StreamRequest_WhoRequest
+	//	*StreamRequest_MuteRequest
+	//	*StreamRequest_UnmuteRequest
+	//	*StreamRequest_KickRequest
+	//	*StreamRequest_BanRequest
+	//	*StreamRequest_UnbanRequest
+	//	*StreamRequest_RoleRequest
+	//	*StreamRequest_PasswordRequest
+	Action        isStreamRequest_Action `protobuf_oneof:"action"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_proto_chitchat_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamRequest) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetParentId() uint64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetEmote() bool {
+	if x != nil {
+		return x.Emote
+	}
+	return false
+}
+
+func (x *StreamRequest) GetAction() isStreamRequest_Action {
+	if x != nil {
+		return x.Action
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetEditRequest() *StreamRequest_Edit {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_EditRequest); ok {
+			return x.EditRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetDeleteRequest() *StreamRequest_Delete {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_DeleteRequest); ok {
+			return x.DeleteRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetReactRequest() *StreamRequest_React {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_ReactRequest); ok {
+			return x.ReactRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetNickRequest() *StreamRequest_Nick {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_NickRequest); ok {
+			return x.NickRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetPrivateRequest() *StreamRequest_Private {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_PrivateRequest); ok {
+			return x.PrivateRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetJoinRequest() *StreamRequest_Join {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_JoinRequest); ok {
+			return x.JoinRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetWhoRequest() *StreamRequest_Who {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_WhoRequest); ok {
+			return x.WhoRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetMuteRequest() *StreamRequest_Mute {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_MuteRequest); ok {
+			return x.MuteRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetUnmuteRequest() *StreamRequest_Unmute {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_UnmuteRequest); ok {
+			return x.UnmuteRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetKickRequest() *StreamRequest_Kick {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_KickRequest); ok {
+			return x.KickRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetBanRequest() *StreamRequest_Ban {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_BanRequest); ok {
+			return x.BanRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetUnbanRequest() *StreamRequest_Unban {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_UnbanRequest); ok {
+			return x.UnbanRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetRoleRequest() *StreamRequest_SetRole {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_RoleRequest); ok {
+			return x.RoleRequest
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetPasswordRequest() *StreamRequest_SetPassword {
+	if x != nil {
+		if x, ok := x.Action.(*StreamRequest_PasswordRequest); ok {
+			return x.PasswordRequest
+		}
+	}
+	return nil
+}
+
+type isStreamRequest_Action interface {
+	isStreamRequest_Action()
+}
+
+type StreamRequest_EditRequest struct {
+	EditRequest *StreamRequest_Edit `protobuf:"bytes,4,opt,name=edit_request,json=editRequest,proto3,oneof"`
+}
+
+type StreamRequest_DeleteRequest struct {
+	DeleteRequest *StreamRequest_Delete `protobuf:"bytes,5,opt,name=delete_request,json=deleteRequest,proto3,oneof"`
+}
+
+type StreamRequest_ReactRequest struct {
+	ReactRequest *StreamRequest_React `protobuf:"bytes,6,opt,name=react_request,json=reactRequest,proto3,oneof"`
+}
+
+type StreamRequest_NickRequest struct {
+	NickRequest *StreamRequest_Nick `protobuf:"bytes,9,opt,name=nick_request,json=nickRequest,proto3,oneof"`
+}
+
+type StreamRequest_PrivateRequest struct {
+	PrivateRequest *StreamRequest_Private `protobuf:"bytes,10,opt,name=private_request,json=privateRequest,proto3,oneof"`
+}
+
+type StreamRequest_JoinRequest struct {
+	JoinRequest *StreamRequest_Join `protobuf:"bytes,11,opt,name=join_request,json=joinRequest,proto3,oneof"`
+}
+
+type StreamRequest_WhoRequest struct {
+	WhoRequest *StreamRequest_Who `protobuf:"bytes,12,opt,name=who_request,json=whoRequest,proto3,oneof"`
+}
+
+type StreamRequest_MuteRequest struct {
+	MuteRequest *StreamRequest_Mute `protobuf:"bytes,13,opt,name=mute_request,json=muteRequest,proto3,oneof"`
+}
+
+type StreamRequest_UnmuteRequest struct {
+	UnmuteRequest *StreamRequest_Unmute `protobuf:"bytes,14,opt,name=unmute_request,json=unmuteRequest,proto3,oneof"`
+}
+
+type StreamRequest_KickRequest struct {
+	KickRequest *StreamRequest_Kick `protobuf:"bytes,15,opt,name=kick_request,json=kickRequest,proto3,oneof"`
+}
+
+type StreamRequest_BanRequest struct {
+	BanRequest *StreamRequest_Ban `protobuf:"bytes,16,opt,name=ban_request,json=banRequest,proto3,oneof"`
+}
+
+type StreamRequest_UnbanRequest struct {
+	UnbanRequest *StreamRequest_Unban `protobuf:"bytes,17,opt,name=unban_request,json=unbanRequest,proto3,oneof"`
+}
+
+type StreamRequest_RoleRequest struct {
+	RoleRequest *StreamRequest_SetRole `protobuf:"bytes,18,opt,name=role_request,json=roleRequest,proto3,oneof"`
+}
+
+type StreamRequest_PasswordRequest struct {
+	PasswordRequest *StreamRequest_SetPassword `protobuf:"bytes,19,opt,name=password_request,json=passwordRequest,proto3,oneof"`
+}
+
+func (*StreamRequest_EditRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_DeleteRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_ReactRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_NickRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_PrivateRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_JoinRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_WhoRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_MuteRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_UnmuteRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_KickRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_BanRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_UnbanRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_RoleRequest) isStreamRequest_Action() {}
+
+func (*StreamRequest_PasswordRequest) isStreamRequest_Action() {}
+
+type StreamResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*StreamResponse_ChatMessage
+	//	*StreamResponse_LoginEvent
+	//	*StreamResponse_LogoutEvent
+	//	*StreamResponse_EditEvent
+	//	*StreamResponse_DeleteEvent
+	//	*StreamResponse_ReactionEvent
+	//	*StreamResponse_ErrorEvent
+	//	*StreamResponse_NickEvent
+	//	*StreamResponse_PrivateEvent
+	//	*StreamResponse_JoinEvent
+	//	*StreamResponse_MembersEvent
+	//	*StreamResponse_SystemEvent
+	//	*StreamResponse_KickEvent
+	Event         isStreamResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse) Reset() {
+	*x = StreamResponse{}
+	mi := &file_proto_chitchat_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse) ProtoMessage() {}
+
+func (x *StreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
+func (*StreamResponse) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamResponse) GetTimestamp() uint64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *StreamResponse) GetEvent() isStreamResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetChatMessage() *StreamResponse_Message {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_ChatMessage); ok {
+			return x.ChatMessage
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetLoginEvent() *StreamResponse_Login {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_LoginEvent); ok {
+			return x.LoginEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetLogoutEvent() *StreamResponse_Logout {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_LogoutEvent); ok {
+			return x.LogoutEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetEditEvent() *StreamResponse_Edit {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_EditEvent); ok {
+			return x.EditEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetDeleteEvent() *StreamResponse_Delete {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_DeleteEvent); ok {
+			return x.DeleteEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetReactionEvent() *StreamResponse_Reaction {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_ReactionEvent); ok {
+			return x.ReactionEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetErrorEvent() *StreamResponse_Error {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_ErrorEvent); ok {
+			return x.ErrorEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetNickEvent() *StreamResponse_Nick {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_NickEvent); ok {
+			return x.NickEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetPrivateEvent() *StreamResponse_Private {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_PrivateEvent); ok {
+			return x.PrivateEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetJoinEvent() *StreamResponse_Join {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_JoinEvent); ok {
+			return x.JoinEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetMembersEvent() *StreamResponse_Members {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_MembersEvent); ok {
+			return x.MembersEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetSystemEvent() *StreamResponse_System {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_SystemEvent); ok {
+			return x.SystemEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetKickEvent() *StreamResponse_Kick {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_KickEvent); ok {
+			return x.KickEvent
+		}
+	}
+	return nil
+}
+
+type isStreamResponse_Event interface {
+	isStreamResponse_Event()
+}
+
+type StreamResponse_ChatMessage struct {
+	ChatMessage *StreamResponse_Message `protobuf:"bytes,2,opt,name=chat_message,json=chatMessage,proto3,oneof"`
+}
+
+type StreamResponse_LoginEvent struct {
+	LoginEvent *StreamResponse_Login `protobuf:"bytes,3,opt,name=login_event,json=loginEvent,proto3,oneof"`
+}
+
+type StreamResponse_LogoutEvent struct {
+	LogoutEvent *StreamResponse_Logout `protobuf:"bytes,4,opt,name=logout_event,json=logoutEvent,proto3,oneof"`
+}
+
+type StreamResponse_EditEvent struct {
+	EditEvent *StreamResponse_Edit `protobuf:"bytes,5,opt,name=edit_event,json=editEvent,proto3,oneof"`
+}
+
+type StreamResponse_DeleteEvent struct {
+	DeleteEvent *StreamResponse_Delete `protobuf:"bytes,6,opt,name=delete_event,json=deleteEvent,proto3,oneof"`
+}
+
+type StreamResponse_ReactionEvent struct {
+	ReactionEvent *StreamResponse_Reaction `protobuf:"bytes,7,opt,name=reaction_event,json=reactionEvent,proto3,oneof"`
+}
+
+type StreamResponse_ErrorEvent struct {
+	ErrorEvent *StreamResponse_Error `protobuf:"bytes,8,opt,name=error_event,json=errorEvent,proto3,oneof"`
+}
+
+type StreamResponse_NickEvent struct {
+	NickEvent *StreamResponse_Nick `protobuf:"bytes,9,opt,name=nick_event,json=nickEvent,proto3,oneof"`
+}
+
+type StreamResponse_PrivateEvent struct {
+	PrivateEvent *StreamResponse_Private `protobuf:"bytes,10,opt,name=private_event,json=privateEvent,proto3,oneof"`
+}
+
+type StreamResponse_JoinEvent struct {
+	JoinEvent *StreamResponse_Join `protobuf:"bytes,11,opt,name=join_event,json=joinEvent,proto3,oneof"`
+}
+
+type StreamResponse_MembersEvent struct {
+	MembersEvent *StreamResponse_Members `protobuf:"bytes,12,opt,name=members_event,json=membersEvent,proto3,oneof"`
+}
+
+type StreamResponse_SystemEvent struct {
+	SystemEvent *StreamResponse_System `protobuf:"bytes,13,opt,name=system_event,json=systemEvent,proto3,oneof"`
+}
+
+type StreamResponse_KickEvent struct {
+	KickEvent *StreamResponse_Kick `protobuf:"bytes,14,opt,name=kick_event,json=kickEvent,proto3,oneof"`
+}
+
+func (*StreamResponse_ChatMessage) isStreamResponse_Event() {}
+
+func (*StreamResponse_LoginEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_LogoutEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_EditEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_DeleteEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_ReactionEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_ErrorEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_NickEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_PrivateEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_JoinEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_MembersEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_SystemEvent) isStreamResponse_Event() {}
+
+func (*StreamResponse_KickEvent) isStreamResponse_Event() {}
+
+type StreamRequest_Edit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Edit) Reset() {
+	*x = StreamRequest_Edit{}
+	mi := &file_proto_chitchat_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Edit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Edit) ProtoMessage() {}
+
+func (x *StreamRequest_Edit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Edit.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Edit) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 0}
+}
+
+func (x *StreamRequest_Edit) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StreamRequest_Edit) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type StreamRequest_Delete struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Delete) Reset() {
+	*x = StreamRequest_Delete{}
+	mi := &file_proto_chitchat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Delete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Delete) ProtoMessage() {}
+
+func (x *StreamRequest_Delete) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Delete.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Delete) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 1}
+}
+
+func (x *StreamRequest_Delete) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// Reacting with the same reaction twice removes it again
+type StreamRequest_React struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Reaction      string                 `protobuf:"bytes,2,opt,name=reaction,proto3" json:"reaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_React) Reset() {
+	*x = StreamRequest_React{}
+	mi := &file_proto_chitchat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_React) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_React) ProtoMessage() {}
+
+func (x *StreamRequest_React) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_React.ProtoReflect.Descriptor instead.
+func (*StreamRequest_React) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 2}
+}
+
+func (x *StreamRequest_React) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StreamRequest_React) GetReaction() string {
+	if x != nil {
+		return x.Reaction
+	}
+	return ""
+}
+
+type StreamRequest_Nick struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Nick) Reset() {
+	*x = StreamRequest_Nick{}
+	mi := &file_proto_chitchat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Nick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Nick) ProtoMessage() {}
+
+func (x *StreamRequest_Nick) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Nick.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Nick) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 3}
+}
+
+func (x *StreamRequest_Nick) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type StreamRequest_Private struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Private) Reset() {
+	*x = StreamRequest_Private{}
+	mi := &file_proto_chitchat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Private) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Private) ProtoMessage() {}
+
+func (x *StreamRequest_Private) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Private.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Private) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 4}
+}
+
+func (x *StreamRequest_Private) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamRequest_Private) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type StreamRequest_Join struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Join) Reset() {
+	*x = StreamRequest_Join{}
+	mi := &file_proto_chitchat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Join) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Join) ProtoMessage() {}
+
+func (x *StreamRequest_Join) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Join.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Join) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 5}
+}
+
+func (x *StreamRequest_Join) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+// Lists the members of a room, or of the sender's room if left empty
+type StreamRequest_Who struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Who) Reset() {
+	*x = StreamRequest_Who{}
+	mi := &file_proto_chitchat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Who) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Who) ProtoMessage() {}
+
+func (x *StreamRequest_Who) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Who.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Who) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 6}
+}
+
+func (x *StreamRequest_Who) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+// The moderation requests below require the sender to be a moderator or owner
+type StreamRequest_Mute struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Username        string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	DurationSeconds uint64                 `protobuf:"varint,2,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	Reason          string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Mute) Reset() {
+	*x = StreamRequest_Mute{}
+	mi := &file_proto_chitchat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Mute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Mute) ProtoMessage() {}
+
+func (x *StreamRequest_Mute) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Mute.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Mute) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 7}
+}
+
+func (x *StreamRequest_Mute) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamRequest_Mute) GetDurationSeconds() uint64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *StreamRequest_Mute) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StreamRequest_Unmute struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Unmute) Reset() {
+	*x = StreamRequest_Unmute{}
+	mi := &file_proto_chitchat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Unmute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Unmute) ProtoMessage() {}
+
+func (x *StreamRequest_Unmute) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Unmute.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Unmute) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 8}
+}
+
+func (x *StreamRequest_Unmute) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type StreamRequest_Kick struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Kick) Reset() {
+	*x = StreamRequest_Kick{}
+	mi := &file_proto_chitchat_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Kick) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Kick) ProtoMessage() {}
+
+func (x *StreamRequest_Kick) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Kick.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Kick) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 9}
+}
+
+func (x *StreamRequest_Kick) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamRequest_Kick) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StreamRequest_Ban struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Ban) Reset() {
+	*x = StreamRequest_Ban{}
+	mi := &file_proto_chitchat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Ban) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Ban) ProtoMessage() {}
+
+func (x *StreamRequest_Ban) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Ban.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Ban) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 10}
+}
+
+func (x *StreamRequest_Ban) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamRequest_Ban) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StreamRequest_Unban struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_Unban) Reset() {
+	*x = StreamRequest_Unban{}
+	mi := &file_proto_chitchat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_Unban) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_Unban) ProtoMessage() {}
+
+func (x *StreamRequest_Unban) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_Unban.ProtoReflect.Descriptor instead.
+func (*StreamRequest_Unban) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 11}
+}
+
+func (x *StreamRequest_Unban) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+// Only the owner may change roles
+type StreamRequest_SetRole struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Role          Role                   `protobuf:"varint,2,opt,name=role,proto3,enum=proto.Role" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_SetRole) Reset() {
+	*x = StreamRequest_SetRole{}
+	mi := &file_proto_chitchat_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_SetRole) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_SetRole) ProtoMessage() {}
+
+func (x *StreamRequest_SetRole) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_SetRole.ProtoReflect.Descriptor instead.
+func (*StreamRequest_SetRole) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 12}
+}
+
+func (x *StreamRequest_SetRole) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamRequest_SetRole) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_MEMBER
+}
+
+// Sets the password of the sender's username, which is then required to connect with it
+type StreamRequest_SetPassword struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Password      string                 `protobuf:"bytes,1,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest_SetPassword) Reset() {
+	*x = StreamRequest_SetPassword{}
+	mi := &file_proto_chitchat_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest_SetPassword) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest_SetPassword) ProtoMessage() {}
+
+func (x *StreamRequest_SetPassword) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest_SetPassword.ProtoReflect.Descriptor instead.
+func (*StreamRequest_SetPassword) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{9, 13}
+}
+
+func (x *StreamRequest_SetPassword) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type StreamResponse_Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Id            uint64                 `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	ParentId      uint64                 `protobuf:"varint,4,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Emote         bool                   `protobuf:"varint,5,opt,name=emote,proto3" json:"emote,omitempty"`
+	Room          string                 `protobuf:"bytes,6,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Message) Reset() {
+	*x = StreamResponse_Message{}
+	mi := &file_proto_chitchat_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Message) ProtoMessage() {}
+
+func (x *StreamResponse_Message) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse_Message.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Message) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 0}
+}
+
+func (x *StreamResponse_Message) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamResponse_Message) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StreamResponse_Message) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StreamResponse_Message) GetParentId() uint64 {
+	if x != nil {
+		return x.ParentId
+	}
+	return 0
+}
+
+func (x *StreamResponse_Message) GetEmote() bool {
+	if x != nil {
+		return x.Emote
+	}
+	return false
+}
+
+func (x *StreamResponse_Message) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type StreamResponse_Login struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Login) Reset() {
+	*x = StreamResponse_Login{}
+	mi := &file_proto_chitchat_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Login) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Login) ProtoMessage() {}
+
+func (x *StreamResponse_Login) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse_Login.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Login) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 1}
+}
+
+func (x *StreamResponse_Login) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type StreamResponse_Logout struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Logout) Reset() {
+	*x = StreamResponse_Logout{}
+	mi := &file_proto_chitchat_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Logout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Logout) ProtoMessage() {}
+
+func (x *StreamResponse_Logout) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse_Logout.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Logout) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 2}
+}
+
+func (x *StreamResponse_Logout) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type StreamResponse_Edit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Edit) Reset() {
+	*x = StreamResponse_Edit{}
+	mi := &file_proto_chitchat_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Edit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Edit) ProtoMessage() {}
+
+func (x *StreamResponse_Edit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse_Edit.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Edit) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 3}
+}
+
+func (x *StreamResponse_Edit) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StreamResponse_Edit) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamResponse_Edit) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type StreamResponse_Delete struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConnectRequest) Reset() {
-	*x = ConnectRequest{}
-	mi := &file_proto_chitchat_proto_msgTypes[0]
+func (x *StreamResponse_Delete) Reset() {
+	*x = StreamResponse_Delete{}
+	mi := &file_proto_chitchat_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConnectRequest) String() string {
+func (x *StreamResponse_Delete) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConnectRequest) ProtoMessage() {}
+func (*StreamResponse_Delete) ProtoMessage() {}
 
-func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[0]
+func (x *StreamResponse_Delete) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -54,48 +2376,50 @@ func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
-func (*ConnectRequest) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use StreamResponse_Delete.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Delete) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 4}
 }
 
-func (x *ConnectRequest) GetTimestamp() uint64 {
+func (x *StreamResponse_Delete) GetId() uint64 {
 	if x != nil {
-		return x.Timestamp
+		return x.Id
 	}
 	return 0
 }
 
-func (x *ConnectRequest) GetUsername() string {
+func (x *StreamResponse_Delete) GetUsername() string {
 	if x != nil {
 		return x.Username
 	}
 	return ""
 }
 
-type ConnectResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+// Carries the aggregated reaction counts of a message after a user reacted to it
+type StreamResponse_Reaction struct {
+	state         protoimpl.MessageState          `protogen:"open.v1"`
+	Id            uint64                          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                          `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Counts        []*StreamResponse_ReactionCount `protobuf:"bytes,3,rep,name=counts,proto3" json:"counts,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ConnectResponse) Reset() {
-	*x = ConnectResponse{}
-	mi := &file_proto_chitchat_proto_msgTypes[1]
+func (x *StreamResponse_Reaction) Reset() {
+	*x = StreamResponse_Reaction{}
+	mi := &file_proto_chitchat_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ConnectResponse) String() string {
+func (x *StreamResponse_Reaction) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ConnectResponse) ProtoMessage() {}
+func (*StreamResponse_Reaction) ProtoMessage() {}
 
-func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[1]
+func (x *StreamResponse_Reaction) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -106,49 +2430,55 @@ func (x *ConnectResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ConnectResponse.ProtoReflect.Descriptor instead.
-func (*ConnectResponse) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use StreamResponse_Reaction.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Reaction) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 5}
 }
 
-func (x *ConnectResponse) GetTimestamp() uint64 {
+func (x *StreamResponse_Reaction) GetId() uint64 {
 	if x != nil {
-		return x.Timestamp
+		return x.Id
 	}
 	return 0
 }
 
-func (x *ConnectResponse) GetToken() string {
+func (x *StreamResponse_Reaction) GetUsername() string {
 	if x != nil {
-		return x.Token
+		return x.Username
 	}
 	return ""
 }
 
-type StreamRequest struct {
+func (x *StreamResponse_Reaction) GetCounts() []*StreamResponse_ReactionCount {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+type StreamResponse_ReactionCount struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Reaction      string                 `protobuf:"bytes,1,opt,name=reaction,proto3" json:"reaction,omitempty"`
+	Count         uint32                 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamRequest) Reset() {
-	*x = StreamRequest{}
-	mi := &file_proto_chitchat_proto_msgTypes[2]
+func (x *StreamResponse_ReactionCount) Reset() {
+	*x = StreamResponse_ReactionCount{}
+	mi := &file_proto_chitchat_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamRequest) String() string {
+func (x *StreamResponse_ReactionCount) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamRequest) ProtoMessage() {}
+func (*StreamResponse_ReactionCount) ProtoMessage() {}
 
-func (x *StreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[2]
+func (x *StreamResponse_ReactionCount) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -159,60 +2489,101 @@ func (x *StreamRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
-func (*StreamRequest) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use StreamResponse_ReactionCount.ProtoReflect.Descriptor instead.
+func (*StreamResponse_ReactionCount) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 6}
 }
 
-func (x *StreamRequest) GetTimestamp() uint64 {
+func (x *StreamResponse_ReactionCount) GetReaction() string {
 	if x != nil {
-		return x.Timestamp
+		return x.Reaction
+	}
+	return ""
+}
+
+func (x *StreamResponse_ReactionCount) GetCount() uint32 {
+	if x != nil {
+		return x.Count
 	}
 	return 0
 }
 
-func (x *StreamRequest) GetToken() string {
+// Sent only to the client whose request was rejected
+type StreamResponse_Error struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Code          StreamResponse_Error_Code `protobuf:"varint,1,opt,name=code,proto3,enum=proto.StreamResponse_Error_Code" json:"code,omitempty"`
+	Reason        string                    `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Error) Reset() {
+	*x = StreamResponse_Error{}
+	mi := &file_proto_chitchat_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Error) ProtoMessage() {}
+
+func (x *StreamResponse_Error) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[32]
 	if x != nil {
-		return x.Token
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *StreamRequest) GetMessage() string {
+// Deprecated: Use StreamResponse_Error.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Error) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 7}
+}
+
+func (x *StreamResponse_Error) GetCode() StreamResponse_Error_Code {
 	if x != nil {
-		return x.Message
+		return x.Code
+	}
+	return StreamResponse_Error_UNKNOWN
+}
+
+func (x *StreamResponse_Error) GetReason() string {
+	if x != nil {
+		return x.Reason
 	}
 	return ""
 }
 
-type StreamResponse struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp uint64                 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	// Types that are valid to be assigned to Event:
-	//
-	//	*StreamResponse_ChatMessage
-	//	*StreamResponse_LoginEvent
-	//	*StreamResponse_LogoutEvent
-	Event         isStreamResponse_Event `protobuf_oneof:"event"`
+type StreamResponse_Nick struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldUsername   string                 `protobuf:"bytes,1,opt,name=old_username,json=oldUsername,proto3" json:"old_username,omitempty"`
+	NewUsername   string                 `protobuf:"bytes,2,opt,name=new_username,json=newUsername,proto3" json:"new_username,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamResponse) Reset() {
-	*x = StreamResponse{}
-	mi := &file_proto_chitchat_proto_msgTypes[3]
+func (x *StreamResponse_Nick) Reset() {
+	*x = StreamResponse_Nick{}
+	mi := &file_proto_chitchat_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamResponse) String() string {
+func (x *StreamResponse_Nick) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamResponse) ProtoMessage() {}
+func (*StreamResponse_Nick) ProtoMessage() {}
 
-func (x *StreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[3]
+func (x *StreamResponse_Nick) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -223,97 +2594,161 @@ func (x *StreamResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
-func (*StreamResponse) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use StreamResponse_Nick.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Nick) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 8}
 }
 
-func (x *StreamResponse) GetTimestamp() uint64 {
+func (x *StreamResponse_Nick) GetOldUsername() string {
 	if x != nil {
-		return x.Timestamp
+		return x.OldUsername
 	}
-	return 0
+	return ""
 }
 
-func (x *StreamResponse) GetEvent() isStreamResponse_Event {
+func (x *StreamResponse_Nick) GetNewUsername() string {
 	if x != nil {
-		return x.Event
+		return x.NewUsername
 	}
-	return nil
+	return ""
 }
 
-func (x *StreamResponse) GetChatMessage() *StreamResponse_Message {
+// Only sent to the sender and the recipient
+type StreamResponse_Private struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse_Private) Reset() {
+	*x = StreamResponse_Private{}
+	mi := &file_proto_chitchat_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse_Private) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse_Private) ProtoMessage() {}
+
+func (x *StreamResponse_Private) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[34]
 	if x != nil {
-		if x, ok := x.Event.(*StreamResponse_ChatMessage); ok {
-			return x.ChatMessage
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *StreamResponse) GetLoginEvent() *StreamResponse_Login {
+// Deprecated: Use StreamResponse_Private.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Private) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 9}
+}
+
+func (x *StreamResponse_Private) GetFrom() string {
 	if x != nil {
-		if x, ok := x.Event.(*StreamResponse_LoginEvent); ok {
-			return x.LoginEvent
-		}
+		return x.From
 	}
-	return nil
+	return ""
 }
 
-func (x *StreamResponse) GetLogoutEvent() *StreamResponse_Logout {
+func (x *StreamResponse_Private) GetTo() string {
 	if x != nil {
-		if x, ok := x.Event.(*StreamResponse_LogoutEvent); ok {
-			return x.LogoutEvent
-		}
+		return x.To
 	}
-	return nil
+	return ""
 }
 
-type isStreamResponse_Event interface {
-	isStreamResponse_Event()
+func (x *StreamResponse_Private) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
 }
 
-type StreamResponse_ChatMessage struct {
-	ChatMessage *StreamResponse_Message `protobuf:"bytes,2,opt,name=chat_message,json=chatMessage,proto3,oneof"`
+type StreamResponse_Join struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type StreamResponse_LoginEvent struct {
-	LoginEvent *StreamResponse_Login `protobuf:"bytes,3,opt,name=login_event,json=loginEvent,proto3,oneof"`
+func (x *StreamResponse_Join) Reset() {
+	*x = StreamResponse_Join{}
+	mi := &file_proto_chitchat_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type StreamResponse_LogoutEvent struct {
-	LogoutEvent *StreamResponse_Logout `protobuf:"bytes,4,opt,name=logout_event,json=logoutEvent,proto3,oneof"`
+func (x *StreamResponse_Join) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamResponse_ChatMessage) isStreamResponse_Event() {}
+func (*StreamResponse_Join) ProtoMessage() {}
 
-func (*StreamResponse_LoginEvent) isStreamResponse_Event() {}
+func (x *StreamResponse_Join) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-func (*StreamResponse_LogoutEvent) isStreamResponse_Event() {}
+// Deprecated: Use StreamResponse_Join.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Join) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 10}
+}
 
-type StreamResponse_Message struct {
+func (x *StreamResponse_Join) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *StreamResponse_Join) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+type StreamResponse_Members struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Room          string                 `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	Usernames     []string               `protobuf:"bytes,2,rep,name=usernames,proto3" json:"usernames,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamResponse_Message) Reset() {
-	*x = StreamResponse_Message{}
-	mi := &file_proto_chitchat_proto_msgTypes[4]
+func (x *StreamResponse_Members) Reset() {
+	*x = StreamResponse_Members{}
+	mi := &file_proto_chitchat_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamResponse_Message) String() string {
+func (x *StreamResponse_Members) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamResponse_Message) ProtoMessage() {}
+func (*StreamResponse_Members) ProtoMessage() {}
 
-func (x *StreamResponse_Message) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[4]
+func (x *StreamResponse_Members) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,47 +2759,48 @@ func (x *StreamResponse_Message) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamResponse_Message.ProtoReflect.Descriptor instead.
-func (*StreamResponse_Message) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{3, 0}
+// Deprecated: Use StreamResponse_Members.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Members) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 11}
 }
 
-func (x *StreamResponse_Message) GetUsername() string {
+func (x *StreamResponse_Members) GetRoom() string {
 	if x != nil {
-		return x.Username
+		return x.Room
 	}
 	return ""
 }
 
-func (x *StreamResponse_Message) GetMessage() string {
+func (x *StreamResponse_Members) GetUsernames() []string {
 	if x != nil {
-		return x.Message
+		return x.Usernames
 	}
-	return ""
+	return nil
 }
 
-type StreamResponse_Login struct {
+// An announcement from the server operators
+type StreamResponse_System struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamResponse_Login) Reset() {
-	*x = StreamResponse_Login{}
-	mi := &file_proto_chitchat_proto_msgTypes[5]
+func (x *StreamResponse_System) Reset() {
+	*x = StreamResponse_System{}
+	mi := &file_proto_chitchat_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamResponse_Login) String() string {
+func (x *StreamResponse_System) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamResponse_Login) ProtoMessage() {}
+func (*StreamResponse_System) ProtoMessage() {}
 
-func (x *StreamResponse_Login) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[5]
+func (x *StreamResponse_System) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -375,40 +2811,41 @@ func (x *StreamResponse_Login) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamResponse_Login.ProtoReflect.Descriptor instead.
-func (*StreamResponse_Login) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{3, 1}
+// Deprecated: Use StreamResponse_System.ProtoReflect.Descriptor instead.
+func (*StreamResponse_System) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 12}
 }
 
-func (x *StreamResponse_Login) GetUsername() string {
+func (x *StreamResponse_System) GetMessage() string {
 	if x != nil {
-		return x.Username
+		return x.Message
 	}
 	return ""
 }
 
-type StreamResponse_Logout struct {
+// The last event a client receives before the server closes its stream
+type StreamResponse_Kick struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamResponse_Logout) Reset() {
-	*x = StreamResponse_Logout{}
-	mi := &file_proto_chitchat_proto_msgTypes[6]
+func (x *StreamResponse_Kick) Reset() {
+	*x = StreamResponse_Kick{}
+	mi := &file_proto_chitchat_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamResponse_Logout) String() string {
+func (x *StreamResponse_Kick) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamResponse_Logout) ProtoMessage() {}
+func (*StreamResponse_Kick) ProtoMessage() {}
 
-func (x *StreamResponse_Logout) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_chitchat_proto_msgTypes[6]
+func (x *StreamResponse_Kick) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chitchat_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,14 +2856,14 @@ func (x *StreamResponse_Logout) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamResponse_Logout.ProtoReflect.Descriptor instead.
-func (*StreamResponse_Logout) Descriptor() ([]byte, []int) {
-	return file_proto_chitchat_proto_rawDescGZIP(), []int{3, 2}
+// Deprecated: Use StreamResponse_Kick.ProtoReflect.Descriptor instead.
+func (*StreamResponse_Kick) Descriptor() ([]byte, []int) {
+	return file_proto_chitchat_proto_rawDescGZIP(), []int{10, 13}
 }
 
-func (x *StreamResponse_Logout) GetUsername() string {
+func (x *StreamResponse_Kick) GetReason() string {
 	if x != nil {
-		return x.Username
+		return x.Reason
 	}
 	return ""
 }
@@ -435,34 +2872,214 @@ var File_proto_chitchat_proto protoreflect.FileDescriptor
 
 const file_proto_chitchat_proto_rawDesc = "" +
 	"\n" +
-	"\x14proto/chitchat.proto\x12\x05proto\"J\n" +
+	"\x14proto/chitchat.proto\x12\x05proto\"f\n" +
 	"\x0eConnectRequest\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\"E\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\"Y\n" +
 	"\x0fConnectResponse\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x14\n" +
-	"\x05token\x18\x02 \x01(\tR\x05token\"]\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x12\n" +
+	"\x04room\x18\x03 \x01(\tR\x04room\"=\n" +
+	"\rThreadRequest\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x04R\x02id\"\x9e\x01\n" +
+	"\x0eThreadResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x125\n" +
+	"\x06parent\x18\x02 \x01(\v2\x1d.proto.StreamResponse.MessageR\x06parent\x127\n" +
+	"\areplies\x18\x03 \x03(\v2\x1d.proto.StreamResponse.MessageR\areplies\"\xeb\x01\n" +
+	"\rExportRequest\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x123\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1b.proto.ExportRequest.FormatR\x06format\x12\x14\n" +
+	"\x05since\x18\x03 \x01(\x03R\x05since\x12\x14\n" +
+	"\x05until\x18\x04 \x01(\x03R\x05until\x12\x12\n" +
+	"\x04room\x18\x05 \x01(\tR\x04room\x12\x1a\n" +
+	"\busername\x18\x06 \x01(\tR\busername\"+\n" +
+	"\x06Format\x12\t\n" +
+	"\x05JSONL\x10\x00\x12\f\n" +
+	"\bMARKDOWN\x10\x01\x12\b\n" +
+	"\x04HTML\x10\x02\"f\n" +
+	"\x0eExportResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x1e\n" +
+	"\n" +
+	"transcript\x18\x02 \x01(\fR\n" +
+	"transcript\x12\x16\n" +
+	"\x06events\x18\x03 \x01(\rR\x06events\"\x99\x02\n" +
+	"\rSearchRequest\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x12\n" +
+	"\x04room\x18\x04 \x01(\tR\x04room\x12%\n" +
+	"\x0efrom_timestamp\x18\x05 \x01(\x04R\rfromTimestamp\x12!\n" +
+	"\fto_timestamp\x18\x06 \x01(\x04R\vtoTimestamp\x12\x14\n" +
+	"\x05since\x18\a \x01(\x03R\x05since\x12\x14\n" +
+	"\x05until\x18\b \x01(\x03R\x05until\x12\x14\n" +
+	"\x05limit\x18\t \x01(\rR\x05limit\x12\x18\n" +
+	"\acontext\x18\n" +
+	" \x01(\rR\acontext\"\xde\x01\n" +
+	"\tSearchHit\x127\n" +
+	"\amessage\x18\x01 \x01(\v2\x1d.proto.StreamResponse.MessageR\amessage\x12\x18\n" +
+	"\alamport\x18\x02 \x01(\x04R\alamport\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\x03R\x04time\x125\n" +
+	"\x06before\x18\x04 \x03(\v2\x1d.proto.StreamResponse.MessageR\x06before\x123\n" +
+	"\x05after\x18\x05 \x03(\v2\x1d.proto.StreamResponse.MessageR\x05after\"\x80\x01\n" +
+	"\x0eSearchResponse\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12$\n" +
+	"\x04hits\x18\x02 \x03(\v2\x10.proto.SearchHitR\x04hits\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\rR\x05total\x12\x14\n" +
+	"\x05terms\x18\x04 \x03(\tR\x05terms\"\xfa\r\n" +
 	"\rStreamRequest\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12\x14\n" +
 	"\x05token\x18\x02 \x01(\tR\x05token\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"\x8a\x03\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1b\n" +
+	"\tparent_id\x18\a \x01(\x04R\bparentId\x12\x14\n" +
+	"\x05emote\x18\b \x01(\bR\x05emote\x12>\n" +
+	"\fedit_request\x18\x04 \x01(\v2\x19.proto.StreamRequest.EditH\x00R\veditRequest\x12D\n" +
+	"\x0edelete_request\x18\x05 \x01(\v2\x1b.proto.StreamRequest.DeleteH\x00R\rdeleteRequest\x12A\n" +
+	"\rreact_request\x18\x06 \x01(\v2\x1a.proto.StreamRequest.ReactH\x00R\freactRequest\x12>\n" +
+	"\fnick_request\x18\t \x01(\v2\x19.proto.StreamRequest.NickH\x00R\vnickRequest\x12G\n" +
+	"\x0fprivate_request\x18\n" +
+	" \x01(\v2\x1c.proto.StreamRequest.PrivateH\x00R\x0eprivateRequest\x12>\n" +
+	"\fjoin_request\x18\v \x01(\v2\x19.proto.StreamRequest.JoinH\x00R\vjoinRequest\x12;\n" +
+	"\vwho_request\x18\f \x01(\v2\x18.proto.StreamRequest.WhoH\x00R\n" +
+	"whoRequest\x12>\n" +
+	"\fmute_request\x18\r \x01(\v2\x19.proto.StreamRequest.MuteH\x00R\vmuteRequest\x12D\n" +
+	"\x0eunmute_request\x18\x0e \x01(\v2\x1b.proto.StreamRequest.UnmuteH\x00R\runmuteRequest\x12>\n" +
+	"\fkick_request\x18\x0f \x01(\v2\x19.proto.StreamRequest.KickH\x00R\vkickRequest\x12;\n" +
+	"\vban_request\x18\x10 \x01(\v2\x18.proto.StreamRequest.BanH\x00R\n" +
+	"banRequest\x12A\n" +
+	"\runban_request\x18\x11 \x01(\v2\x1a.proto.StreamRequest.UnbanH\x00R\funbanRequest\x12A\n" +
+	"\frole_request\x18\x12 \x01(\v2\x1c.proto.StreamRequest.SetRoleH\x00R\vroleRequest\x12M\n" +
+	"\x10password_request\x18\x13 \x01(\v2 .proto.StreamRequest.SetPasswordH\x00R\x0fpasswordRequest\x1a0\n" +
+	"\x04Edit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x1a\x18\n" +
+	"\x06Delete\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x1a3\n" +
+	"\x05React\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\breaction\x18\x02 \x01(\tR\breaction\x1a\"\n" +
+	"\x04Nick\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x1a?\n" +
+	"\aPrivate\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x1a\x1a\n" +
+	"\x04Join\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x1a\x19\n" +
+	"\x03Who\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x1ae\n" +
+	"\x04Mute\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12)\n" +
+	"\x10duration_seconds\x18\x02 \x01(\x04R\x0fdurationSeconds\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x1a$\n" +
+	"\x06Unmute\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x1a:\n" +
+	"\x04Kick\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x1a9\n" +
+	"\x03Ban\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x1a#\n" +
+	"\x05Unban\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x1aF\n" +
+	"\aSetRole\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1f\n" +
+	"\x04role\x18\x02 \x01(\x0e2\v.proto.RoleR\x04role\x1a)\n" +
+	"\vSetPassword\x12\x1a\n" +
+	"\bpassword\x18\x01 \x01(\tR\bpasswordB\b\n" +
+	"\x06action\"\xa6\x10\n" +
 	"\x0eStreamResponse\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\x04R\ttimestamp\x12B\n" +
 	"\fchat_message\x18\x02 \x01(\v2\x1d.proto.StreamResponse.MessageH\x00R\vchatMessage\x12>\n" +
 	"\vlogin_event\x18\x03 \x01(\v2\x1b.proto.StreamResponse.LoginH\x00R\n" +
 	"loginEvent\x12A\n" +
-	"\flogout_event\x18\x04 \x01(\v2\x1c.proto.StreamResponse.LogoutH\x00R\vlogoutEvent\x1a?\n" +
+	"\flogout_event\x18\x04 \x01(\v2\x1c.proto.StreamResponse.LogoutH\x00R\vlogoutEvent\x12;\n" +
+	"\n" +
+	"edit_event\x18\x05 \x01(\v2\x1a.proto.StreamResponse.EditH\x00R\teditEvent\x12A\n" +
+	"\fdelete_event\x18\x06 \x01(\v2\x1c.proto.StreamResponse.DeleteH\x00R\vdeleteEvent\x12G\n" +
+	"\x0ereaction_event\x18\a \x01(\v2\x1e.proto.StreamResponse.ReactionH\x00R\rreactionEvent\x12>\n" +
+	"\verror_event\x18\b \x01(\v2\x1b.proto.StreamResponse.ErrorH\x00R\n" +
+	"errorEvent\x12;\n" +
+	"\n" +
+	"nick_event\x18\t \x01(\v2\x1a.proto.StreamResponse.NickH\x00R\tnickEvent\x12D\n" +
+	"\rprivate_event\x18\n" +
+	" \x01(\v2\x1d.proto.StreamResponse.PrivateH\x00R\fprivateEvent\x12;\n" +
+	"\n" +
+	"join_event\x18\v \x01(\v2\x1a.proto.StreamResponse.JoinH\x00R\tjoinEvent\x12D\n" +
+	"\rmembers_event\x18\f \x01(\v2\x1d.proto.StreamResponse.MembersH\x00R\fmembersEvent\x12A\n" +
+	"\fsystem_event\x18\r \x01(\v2\x1c.proto.StreamResponse.SystemH\x00R\vsystemEvent\x12;\n" +
+	"\n" +
+	"kick_event\x18\x0e \x01(\v2\x1a.proto.StreamResponse.KickH\x00R\tkickEvent\x1a\x96\x01\n" +
 	"\aMessage\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x1a#\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x04R\x02id\x12\x1b\n" +
+	"\tparent_id\x18\x04 \x01(\x04R\bparentId\x12\x14\n" +
+	"\x05emote\x18\x05 \x01(\bR\x05emote\x12\x12\n" +
+	"\x04room\x18\x06 \x01(\tR\x04room\x1a#\n" +
 	"\x05Login\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x1a$\n" +
 	"\x06Logout\x12\x1a\n" +
-	"\busername\x18\x01 \x01(\tR\busernameB\a\n" +
-	"\x05event2\x86\x01\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x1aL\n" +
+	"\x04Edit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x1a4\n" +
+	"\x06Delete\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x1as\n" +
+	"\bReaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12;\n" +
+	"\x06counts\x18\x03 \x03(\v2#.proto.StreamResponse.ReactionCountR\x06counts\x1aA\n" +
+	"\rReactionCount\x12\x1a\n" +
+	"\breaction\x18\x01 \x01(\tR\breaction\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\rR\x05count\x1a\xa6\x02\n" +
+	"\x05Error\x124\n" +
+	"\x04code\x18\x01 \x01(\x0e2 .proto.StreamResponse.Error.CodeR\x04code\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xce\x01\n" +
+	"\x04Code\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\x14\n" +
+	"\x10INVALID_ARGUMENT\x10\x01\x12\r\n" +
+	"\tNOT_FOUND\x10\x02\x12\x12\n" +
+	"\x0eALREADY_EXISTS\x10\x03\x12\x15\n" +
+	"\x11PERMISSION_DENIED\x10\x04\x12\t\n" +
+	"\x05MUTED\x10\x05\x12\r\n" +
+	"\tTHROTTLED\x10\x06\x12\x14\n" +
+	"\x10MESSAGE_TOO_LONG\x10\a\x12\x15\n" +
+	"\x11MESSAGE_TOO_SHORT\x10\b\x12\x14\n" +
+	"\x10INVALID_ENCODING\x10\t\x12\f\n" +
+	"\bFILTERED\x10\n" +
+	"\x1aL\n" +
+	"\x04Nick\x12!\n" +
+	"\fold_username\x18\x01 \x01(\tR\voldUsername\x12!\n" +
+	"\fnew_username\x18\x02 \x01(\tR\vnewUsername\x1aG\n" +
+	"\aPrivate\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x1a6\n" +
+	"\x04Join\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x1a;\n" +
+	"\aMembers\x12\x12\n" +
+	"\x04room\x18\x01 \x01(\tR\x04room\x12\x1c\n" +
+	"\tusernames\x18\x02 \x03(\tR\tusernames\x1a\"\n" +
+	"\x06System\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x1a\x1e\n" +
+	"\x04Kick\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reasonB\a\n" +
+	"\x05event*,\n" +
+	"\x04Role\x12\n" +
+	"\n" +
+	"\x06MEMBER\x10\x00\x12\r\n" +
+	"\tMODERATOR\x10\x01\x12\t\n" +
+	"\x05OWNER\x10\x022\xae\x02\n" +
 	"\x0fChitChatService\x128\n" +
 	"\aConnect\x12\x15.proto.ConnectRequest\x1a\x16.proto.ConnectResponse\x129\n" +
-	"\x06Stream\x12\x14.proto.StreamRequest\x1a\x15.proto.StreamResponse(\x010\x01B\x0fZ\rChitChat/grpcb\x06proto3"
+	"\x06Stream\x12\x14.proto.StreamRequest\x1a\x15.proto.StreamResponse(\x010\x01\x128\n" +
+	"\tGetThread\x12\x14.proto.ThreadRequest\x1a\x15.proto.ThreadResponse\x125\n" +
+	"\x06Export\x12\x14.proto.ExportRequest\x1a\x15.proto.ExportResponse\x125\n" +
+	"\x06Search\x12\x14.proto.SearchRequest\x1a\x15.proto.SearchResponseB\x0fZ\rChitChat/grpcb\x06proto3"
 
 var (
 	file_proto_chitchat_proto_rawDescOnce sync.Once
@@ -476,29 +3093,105 @@ func file_proto_chitchat_proto_rawDescGZIP() []byte {
 	return file_proto_chitchat_proto_rawDescData
 }
 
-var file_proto_chitchat_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_chitchat_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_proto_chitchat_proto_msgTypes = make([]protoimpl.MessageInfo, 39)
 var file_proto_chitchat_proto_goTypes = []any{
-	(*ConnectRequest)(nil),         // 0: proto.ConnectRequest
-	(*ConnectResponse)(nil),        // 1: proto.ConnectResponse
-	(*StreamRequest)(nil),          // 2: proto.StreamRequest
-	(*StreamResponse)(nil),         // 3: proto.StreamResponse
-	(*StreamResponse_Message)(nil), // 4: proto.StreamResponse.Message
-	(*StreamResponse_Login)(nil),   // 5: proto.StreamResponse.Login
-	(*StreamResponse_Logout)(nil),  // 6: proto.StreamResponse.Logout
+	(Role)(0),                            // 0: proto.Role
+	(ExportRequest_Format)(0),            // 1: proto.ExportRequest.Format
+	(StreamResponse_Error_Code)(0),       // 2: proto.StreamResponse.Error.Code
+	(*ConnectRequest)(nil),               // 3: proto.ConnectRequest
+	(*ConnectResponse)(nil),              // 4: proto.ConnectResponse
+	(*ThreadRequest)(nil),                // 5: proto.ThreadRequest
+	(*ThreadResponse)(nil),               // 6: proto.ThreadResponse
+	(*ExportRequest)(nil),                // 7: proto.ExportRequest
+	(*ExportResponse)(nil),               // 8: proto.ExportResponse
+	(*SearchRequest)(nil),                // 9: proto.SearchRequest
+	(*SearchHit)(nil),                    // 10: proto.SearchHit
+	(*SearchResponse)(nil),               // 11: proto.SearchResponse
+	(*StreamRequest)(nil),                // 12: proto.StreamRequest
+	(*StreamResponse)(nil),               // 13: proto.StreamResponse
+	(*StreamRequest_Edit)(nil),           // 14: proto.StreamRequest.Edit
+	(*StreamRequest_Delete)(nil),         // 15: proto.StreamRequest.Delete
+	(*StreamRequest_React)(nil),          // 16: proto.StreamRequest.React
+	(*StreamRequest_Nick)(nil),           // 17: proto.StreamRequest.Nick
+	(*StreamRequest_Private)(nil),        // 18: proto.StreamRequest.Private
+	(*StreamRequest_Join)(nil),           // 19: proto.StreamRequest.Join
+	(*StreamRequest_Who)(nil),            // 20: proto.StreamRequest.Who
+	(*StreamRequest_Mute)(nil),           // 21: proto.StreamRequest.Mute
+	(*StreamRequest_Unmute)(nil),         // 22: proto.StreamRequest.Unmute
+	(*StreamRequest_Kick)(nil),           // 23: proto.StreamRequest.Kick
+	(*StreamRequest_Ban)(nil),            // 24: proto.StreamRequest.Ban
+	(*StreamRequest_Unban)(nil),          // 25: proto.StreamRequest.Unban
+	(*StreamRequest_SetRole)(nil),        // 26: proto.StreamRequest.SetRole
+	(*StreamRequest_SetPassword)(nil),    // 27: proto.StreamRequest.SetPassword
+	(*StreamResponse_Message)(nil),       // 28: proto.StreamResponse.Message
+	(*StreamResponse_Login)(nil),         // 29: proto.StreamResponse.Login
+	(*StreamResponse_Logout)(nil),        // 30: proto.StreamResponse.Logout
+	(*StreamResponse_Edit)(nil),          // 31: proto.StreamResponse.Edit
+	(*StreamResponse_Delete)(nil),        // 32: proto.StreamResponse.Delete
+	(*StreamResponse_Reaction)(nil),      // 33: proto.StreamResponse.Reaction
+	(*StreamResponse_ReactionCount)(nil), // 34: proto.StreamResponse.ReactionCount
+	(*StreamResponse_Error)(nil),         // 35: proto.StreamResponse.Error
+	(*StreamResponse_Nick)(nil),          // 36: proto.StreamResponse.Nick
+	(*StreamResponse_Private)(nil),       // 37: proto.StreamResponse.Private
+	(*StreamResponse_Join)(nil),          // 38: proto.StreamResponse.Join
+	(*StreamResponse_Members)(nil),       // 39: proto.StreamResponse.Members
+	(*StreamResponse_System)(nil),        // 40: proto.StreamResponse.System
+	(*StreamResponse_Kick)(nil),          // 41: proto.StreamResponse.Kick
 }
 var file_proto_chitchat_proto_depIdxs = []int32{
-	4, // 0: proto.StreamResponse.chat_message:type_name -> proto.StreamResponse.Message
-	5, // 1: proto.StreamResponse.login_event:type_name -> proto.StreamResponse.Login
-	6, // 2: proto.StreamResponse.logout_event:type_name -> proto.StreamResponse.Logout
-	0, // 3: proto.ChitChatService.Connect:input_type -> proto.ConnectRequest
-	2, // 4: proto.ChitChatService.Stream:input_type -> proto.StreamRequest
-	1, // 5: proto.ChitChatService.Connect:output_type -> proto.ConnectResponse
-	3, // 6: proto.ChitChatService.Stream:output_type -> proto.StreamResponse
-	5, // [5:7] is the sub-list for method output_type
-	3, // [3:5] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	28, // 0: proto.ThreadResponse.parent:type_name -> proto.StreamResponse.Message
+	28, // 1: proto.ThreadResponse.replies:type_name -> proto.StreamResponse.Message
+	1,  // 2: proto.ExportRequest.format:type_name -> proto.ExportRequest.Format
+	28, // 3: proto.SearchHit.message:type_name -> proto.StreamResponse.Message
+	28, // 4: proto.SearchHit.before:type_name -> proto.StreamResponse.Message
+	28, // 5: proto.SearchHit.after:type_name -> proto.StreamResponse.Message
+	10, // 6: proto.SearchResponse.hits:type_name -> proto.SearchHit
+	14, // 7: proto.StreamRequest.edit_request:type_name -> proto.StreamRequest.Edit
+	15, // 8: proto.StreamRequest.delete_request:type_name -> proto.StreamRequest.Delete
+	16, // 9: proto.StreamRequest.react_request:type_name -> proto.StreamRequest.React
+	17, // 10: proto.StreamRequest.nick_request:type_name -> proto.StreamRequest.Nick
+	18, // 11: proto.StreamRequest.private_request:type_name -> proto.StreamRequest.Private
+	19, // 12: proto.StreamRequest.join_request:type_name -> proto.StreamRequest.Join
+	20, // 13: proto.StreamRequest.who_request:type_name -> proto.StreamRequest.Who
+	21, // 14: proto.StreamRequest.mute_request:type_name -> proto.StreamRequest.Mute
+	22, // 15: proto.StreamRequest.unmute_request:type_name -> proto.StreamRequest.Unmute
+	23, // 16: proto.StreamRequest.kick_request:type_name -> proto.StreamRequest.Kick
+	24, // 17: proto.StreamRequest.ban_request:type_name -> proto.StreamRequest.Ban
+	25, // 18: proto.StreamRequest.unban_request:type_name -> proto.StreamRequest.Unban
+	26, // 19: proto.StreamRequest.role_request:type_name -> proto.StreamRequest.SetRole
+	27, // 20: proto.StreamRequest.password_request:type_name -> proto.StreamRequest.SetPassword
+	28, // 21: proto.StreamResponse.chat_message:type_name -> proto.StreamResponse.Message
+	29, // 22: proto.StreamResponse.login_event:type_name -> proto.StreamResponse.Login
+	30, // 23: proto.StreamResponse.logout_event:type_name -> proto.StreamResponse.Logout
+	31, // 24: proto.StreamResponse.edit_event:type_name -> proto.StreamResponse.Edit
+	32, // 25: proto.StreamResponse.delete_event:type_name -> proto.StreamResponse.Delete
+	33, // 26: proto.StreamResponse.reaction_event:type_name -> proto.StreamResponse.Reaction
+	35, // 27: proto.StreamResponse.error_event:type_name -> proto.StreamResponse.Error
+	36, // 28: proto.StreamResponse.nick_event:type_name -> proto.StreamResponse.Nick
+	37, // 29: proto.StreamResponse.private_event:type_name -> proto.StreamResponse.Private
+	38, // 30: proto.StreamResponse.join_event:type_name -> proto.StreamResponse.Join
+	39, // 31: proto.StreamResponse.members_event:type_name -> proto.StreamResponse.Members
+	40, // 32: proto.StreamResponse.system_event:type_name -> proto.StreamResponse.System
+	41, // 33: proto.StreamResponse.kick_event:type_name -> proto.StreamResponse.Kick
+	0,  // 34: proto.StreamRequest.SetRole.role:type_name -> proto.Role
+	34, // 35: proto.StreamResponse.Reaction.counts:type_name -> proto.StreamResponse.ReactionCount
+	2,  // 36: proto.StreamResponse.Error.code:type_name -> proto.StreamResponse.Error.Code
+	3,  // 37: proto.ChitChatService.Connect:input_type -> proto.ConnectRequest
+	12, // 38: proto.ChitChatService.Stream:input_type -> proto.StreamRequest
+	5,  // 39: proto.ChitChatService.GetThread:input_type -> proto.ThreadRequest
+	7,  // 40: proto.ChitChatService.Export:input_type -> proto.ExportRequest
+	9,  // 41: proto.ChitChatService.Search:input_type -> proto.SearchRequest
+	4,  // 42: proto.ChitChatService.Connect:output_type -> proto.ConnectResponse
+	13, // 43: proto.ChitChatService.Stream:output_type -> proto.StreamResponse
+	6,  // 44: proto.ChitChatService.GetThread:output_type -> proto.ThreadResponse
+	8,  // 45: proto.ChitChatService.Export:output_type -> proto.ExportResponse
+	11, // 46: proto.ChitChatService.Search:output_type -> proto.SearchResponse
+	42, // [42:47] is the sub-list for method output_type
+	37, // [37:42] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_proto_chitchat_proto_init() }
@@ -506,23 +3199,50 @@ func file_proto_chitchat_proto_init() {
 	if File_proto_chitchat_proto != nil {
 		return
 	}
-	file_proto_chitchat_proto_msgTypes[3].OneofWrappers = []any{
+	file_proto_chitchat_proto_msgTypes[9].OneofWrappers = []any{
+		(*StreamRequest_EditRequest)(nil),
+		(*StreamRequest_DeleteRequest)(nil),
+		(*StreamRequest_ReactRequest)(nil),
+		(*StreamRequest_NickRequest)(nil),
+		(*StreamRequest_PrivateRequest)(nil),
+		(*StreamRequest_JoinRequest)(nil),
+		(*StreamRequest_WhoRequest)(nil),
+		(*StreamRequest_MuteRequest)(nil),
+		(*StreamRequest_UnmuteRequest)(nil),
+		(*StreamRequest_KickRequest)(nil),
+		(*StreamRequest_BanRequest)(nil),
+		(*StreamRequest_UnbanRequest)(nil),
+		(*StreamRequest_RoleRequest)(nil),
+		(*StreamRequest_PasswordRequest)(nil),
+	}
+	file_proto_chitchat_proto_msgTypes[10].OneofWrappers = []any{
 		(*StreamResponse_ChatMessage)(nil),
 		(*StreamResponse_LoginEvent)(nil),
 		(*StreamResponse_LogoutEvent)(nil),
+		(*StreamResponse_EditEvent)(nil),
+		(*StreamResponse_DeleteEvent)(nil),
+		(*StreamResponse_ReactionEvent)(nil),
+		(*StreamResponse_ErrorEvent)(nil),
+		(*StreamResponse_NickEvent)(nil),
+		(*StreamResponse_PrivateEvent)(nil),
+		(*StreamResponse_JoinEvent)(nil),
+		(*StreamResponse_MembersEvent)(nil),
+		(*StreamResponse_SystemEvent)(nil),
+		(*StreamResponse_KickEvent)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_chitchat_proto_rawDesc), len(file_proto_chitchat_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   39,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_chitchat_proto_goTypes,
 		DependencyIndexes: file_proto_chitchat_proto_depIdxs,
+		EnumInfos:         file_proto_chitchat_proto_enumTypes,
 		MessageInfos:      file_proto_chitchat_proto_msgTypes,
 	}.Build()
 	File_proto_chitchat_proto = out.File
@@ -19,8 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ChitChatService_Connect_FullMethodName = "/proto.ChitChatService/Connect"
-	ChitChatService_Stream_FullMethodName  = "/proto.ChitChatService/Stream"
+	ChitChatService_Connect_FullMethodName   = "/proto.ChitChatService/Connect"
+	ChitChatService_Stream_FullMethodName    = "/proto.ChitChatService/Stream"
+	ChitChatService_GetThread_FullMethodName = "/proto.ChitChatService/GetThread"
+	ChitChatService_Export_FullMethodName    = "/proto.ChitChatService/Export"
+	ChitChatService_Search_FullMethodName    = "/proto.ChitChatService/Search"
 )
 
 // ChitChatServiceClient is the client API for ChitChatService service.
@@ -29,6 +32,9 @@ const (
 type ChitChatServiceClient interface {
 	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error)
 	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamRequest, StreamResponse], error)
+	GetThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*ThreadResponse, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
 }
 
 type chitChatServiceClient struct {
@@ -62,12 +68,45 @@ func (c *chitChatServiceClient) Stream(ctx context.Context, opts ...grpc.CallOpt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ChitChatService_StreamClient = grpc.BidiStreamingClient[StreamRequest, StreamResponse]
 
+func (c *chitChatServiceClient) GetThread(ctx context.Context, in *ThreadRequest, opts ...grpc.CallOption) (*ThreadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ThreadResponse)
+	err := c.cc.Invoke(ctx, ChitChatService_GetThread_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chitChatServiceClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportResponse)
+	err := c.cc.Invoke(ctx, ChitChatService_Export_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chitChatServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, ChitChatService_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChitChatServiceServer is the server API for ChitChatService service.
 // All implementations must embed UnimplementedChitChatServiceServer
 // for forward compatibility.
 type ChitChatServiceServer interface {
 	Connect(context.Context, *ConnectRequest) (*ConnectResponse, error)
 	Stream(grpc.BidiStreamingServer[StreamRequest, StreamResponse]) error
+	GetThread(context.Context, *ThreadRequest) (*ThreadResponse, error)
+	Export(context.Context, *ExportRequest) (*ExportResponse, error)
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
 	mustEmbedUnimplementedChitChatServiceServer()
 }
 
@@ -84,6 +123,15 @@ func (UnimplementedChitChatServiceServer) Connect(context.Context, *ConnectReque
 func (UnimplementedChitChatServiceServer) Stream(grpc.BidiStreamingServer[StreamRequest, StreamResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
 }
+func (UnimplementedChitChatServiceServer) GetThread(context.Context, *ThreadRequest) (*ThreadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThread not implemented")
+}
+func (UnimplementedChitChatServiceServer) Export(context.Context, *ExportRequest) (*ExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedChitChatServiceServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
 func (UnimplementedChitChatServiceServer) mustEmbedUnimplementedChitChatServiceServer() {}
 func (UnimplementedChitChatServiceServer) testEmbeddedByValue()                         {}
 
@@ -130,6 +178,60 @@ func _ChitChatService_Stream_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type ChitChatService_StreamServer = grpc.BidiStreamingServer[StreamRequest, StreamResponse]
 
+func _ChitChatService_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChitChatServiceServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChitChatService_GetThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChitChatServiceServer).GetThread(ctx, req.(*ThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChitChatService_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChitChatServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChitChatService_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChitChatServiceServer).Export(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChitChatService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChitChatServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChitChatService_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChitChatServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChitChatService_ServiceDesc is the grpc.ServiceDesc for ChitChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -141,6 +243,18 @@ var ChitChatService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Connect",
 			Handler:    _ChitChatService_Connect_Handler,
 		},
+		{
+			MethodName: "GetThread",
+			Handler:    _ChitChatService_GetThread_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _ChitChatService_Export_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _ChitChatService_Search_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -6,4 +6,5 @@ set -xeu pipefail
 
 go build -o $PROJECT_ROOT/ChitChatClient $PROJECT_ROOT/client/*.go
 go build -o $PROJECT_ROOT/ChitChatServer $PROJECT_ROOT/server/*.go
+go build -o $PROJECT_ROOT/chitchat-admin $PROJECT_ROOT/admin/*.go
 
@@ -8,5 +8,5 @@ PROTO_DIR=grpc
 protoc -I ${PROJECT_ROOT} \
     --go_out=. --go_opt=paths=source_relative \
     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
-    ${PROTO_DIR}/chitchat.proto
+    ${PROTO_DIR}/chitchat.proto ${PROTO_DIR}/admin.proto
 
@@ -0,0 +1,22 @@
+#/usr/bin/env bash
+
+# Waits until the server reports SERVING through its health service
+# Usage: wait_for_server.sh [address] [timeout in seconds]
+
+PROJECT_ROOT=$(dirname $( cd -- "$( dirname -- "${BASH_SOURCE[0]}" )" &> /dev/null && pwd ))
+
+ADDRESS=${1:-localhost:5001}
+TIMEOUT=${2:-30}
+
+set -eu pipefail
+
+for ((i = 0; i < TIMEOUT * 2; i++)); do
+    if $PROJECT_ROOT/chitchat-admin -server $ADDRESS health > /dev/null 2>&1; then
+        echo "server at $ADDRESS is ready"
+        exit 0
+    fi
+    sleep 0.5
+done
+
+echo "server at $ADDRESS did not become ready within ${TIMEOUT}s" >&2
+exit 1
@@ -0,0 +1,356 @@
+package main
+
+// Handlers for the requests a client can send over its stream
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+)
+
+const defaultRoom = "general"
+
+func (s *Server) handleChatMessage(client *Client, eventTimestamp uint64, message string, parentID uint64, emote bool) {
+	message, ok := s.validateMessage(client, eventTimestamp, message)
+	if !ok {
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"received message\", username=\"%v\", message=\"%v\"", eventTimestamp, client.username, message)
+
+	s.mu.Lock()
+	if parentID != 0 {
+		parent := s.history.ThreadRoot(parentID)
+		if parent == nil || parent.room != client.room {
+			s.mu.Unlock()
+			utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"unknown parent\", username=\"%v\", id=\"%v\"", eventTimestamp, client.username, parentID)
+			s.Reject(client, pb.StreamResponse_Error_NOT_FOUND, fmt.Sprintf("unknown message #%d", parentID))
+			return
+		}
+		parentID = parent.id
+	}
+	stored := s.history.Add(client.username, client.room, message, parentID, emote)
+	s.mu.Unlock()
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_ChatMessage{
+			ChatMessage: stored.ToProto(),
+		},
+	}
+
+	go s.BroadcastRoom(stored.room, response)
+}
+
+// modifiableMessage looks up a message the client is allowed to edit or delete
+// Only the author of a message or a moderator may modify it
+func (s *Server) modifiableMessage(client *Client, eventTimestamp uint64, id uint64) *StoredMessage {
+	stored := s.history.Get(id)
+	if stored == nil {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"unknown message\", username=\"%v\", id=\"%v\"", eventTimestamp, client.username, id)
+		s.Reject(client, pb.StreamResponse_Error_NOT_FOUND, fmt.Sprintf("unknown message #%d", id))
+		return nil
+	}
+
+	if stored.author != client.username && s.store.Role(client.username).rank() < Moderator.rank() {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused modification\", username=\"%v\", id=\"%v\", reason=\"not the author\"", eventTimestamp, client.username, id)
+		s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, fmt.Sprintf("message #%d was not written by you", id))
+		return nil
+	}
+
+	return stored
+}
+
+func (s *Server) handleEdit(client *Client, eventTimestamp uint64, edit *pb.StreamRequest_Edit) {
+	message, ok := s.validateMessage(client, eventTimestamp, edit.GetMessage())
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	stored := s.modifiableMessage(client, eventTimestamp, edit.GetId())
+	if stored == nil {
+		s.mu.Unlock()
+		return
+	}
+	stored.message = message
+	s.mu.Unlock()
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"edited message\", username=\"%v\", id=\"%v\", message=\"%v\"", eventTimestamp, client.username, stored.id, message)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_EditEvent{
+			EditEvent: &pb.StreamResponse_Edit{
+				Id:       stored.id,
+				Username: client.username,
+				Message:  message,
+			},
+		},
+	}
+
+	go s.BroadcastRoom(stored.room, response)
+}
+
+func (s *Server) handleDelete(client *Client, eventTimestamp uint64, del *pb.StreamRequest_Delete) {
+	s.mu.Lock()
+	stored := s.modifiableMessage(client, eventTimestamp, del.GetId())
+	if stored == nil {
+		s.mu.Unlock()
+		return
+	}
+	stored.deleted = true
+	s.mu.Unlock()
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"deleted message\", username=\"%v\", id=\"%v\"", eventTimestamp, client.username, stored.id)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_DeleteEvent{
+			DeleteEvent: &pb.StreamResponse_Delete{
+				Id:       stored.id,
+				Username: client.username,
+			},
+		},
+	}
+
+	go s.BroadcastRoom(stored.room, response)
+}
+
+// maxReactionLength is the most characters a reaction may have
+const maxReactionLength = 8
+
+func (s *Server) handleReact(client *Client, eventTimestamp uint64, react *pb.StreamRequest_React) {
+	// Reactions are shown to everyone in the room like messages, so they go through the same pipeline
+	reaction, err := s.validator.Validate(react.GetReaction(), MessageLimits{MaxRunes: maxReactionLength, MaxLines: 1})
+	if err != nil {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"invalid reaction\", username=\"%v\", reaction=%q, reason=\"%v\"", eventTimestamp, client.username, react.GetReaction(), err)
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, fmt.Sprintf("reactions must be 1 to %d characters on one line", maxReactionLength))
+		return
+	}
+
+	s.mu.Lock()
+	stored := s.history.Get(react.GetId())
+	// Messages of other rooms are not known to the client
+	if stored == nil || stored.room != client.room {
+		s.mu.Unlock()
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"unknown message\", username=\"%v\", id=\"%v\"", eventTimestamp, client.username, react.GetId())
+		s.Reject(client, pb.StreamResponse_Error_NOT_FOUND, fmt.Sprintf("unknown message #%d", react.GetId()))
+		return
+	}
+	stored.ToggleReaction(client.username, reaction)
+	counts := stored.ReactionCounts()
+	s.mu.Unlock()
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"reaction\", username=\"%v\", id=\"%v\", reaction=\"%v\"", eventTimestamp, client.username, stored.id, reaction)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_ReactionEvent{
+			ReactionEvent: &pb.StreamResponse_Reaction{
+				Id:       stored.id,
+				Username: client.username,
+				Counts:   counts,
+			},
+		},
+	}
+
+	go s.BroadcastRoom(stored.room, response)
+}
+
+// validName checks that a username or room name is non-empty, reasonably short and free of whitespace
+// Names are shown as they are, so a name the validation pipeline would change is refused rather than cleaned up.
+// So are invisible formatting characters such as bidi overrides, which could make a name pass for another
+func validName(name string) bool {
+	if name == "" || utf8.RuneCountInString(name) > 32 {
+		return false
+	}
+	if _, err := ValidateUTF8(name, MessageLimits{}); err != nil {
+		return false
+	}
+	if stripped, _ := StripEscapes(name, MessageLimits{}); stripped != name {
+		return false
+	}
+	if stripped, _ := StripControls(name, MessageLimits{}); stripped != name {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.In(r, unicode.Cf)
+	}) == -1
+}
+
+// invalidNameReason explains the rules of validName
+const invalidNameReason = "must be 1 to 32 characters without spaces or control characters"
+
+func (s *Server) handleNick(client *Client, eventTimestamp uint64, nick *pb.StreamRequest_Nick) {
+	username := nick.GetUsername()
+	if !validName(username) {
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, "usernames "+invalidNameReason)
+		return
+	}
+
+	s.mu.Lock()
+	if s.store.IsBanned(username, "") {
+		s.mu.Unlock()
+		s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, "that username is banned")
+		return
+	}
+	if s.store.Protected(username) {
+		s.mu.Unlock()
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused nick change\", username=\"%v\", nick=\"%v\", reason=\"protected username\"", eventTimestamp, client.username, username)
+		s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, "that username requires a password, connect with it instead")
+		return
+	}
+	if s.usernames[username] {
+		s.mu.Unlock()
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused nick change\", username=\"%v\", nick=\"%v\", reason=\"username already exists\"", eventTimestamp, client.username, username)
+		s.Reject(client, pb.StreamResponse_Error_ALREADY_EXISTS, "username already in use")
+		return
+	}
+
+	old := client.username
+	delete(s.usernames, old)
+	s.usernames[username] = true
+	client.username = username
+	s.history.RenameAuthor(old, username)
+	s.mu.Unlock()
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"nick change\", username=\"%v\", nick=\"%v\"", eventTimestamp, old, username)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_NickEvent{
+			NickEvent: &pb.StreamResponse_Nick{
+				OldUsername: old,
+				NewUsername: username,
+			},
+		},
+	}
+
+	go s.Broadcast(response)
+}
+
+func (s *Server) handlePrivate(client *Client, eventTimestamp uint64, private *pb.StreamRequest_Private) {
+	message, ok := s.validateMessage(client, eventTimestamp, private.GetMessage())
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	recipient := s.clientByName(private.GetUsername())
+	s.mu.Unlock()
+
+	if recipient == nil {
+		s.Reject(client, pb.StreamResponse_Error_NOT_FOUND, fmt.Sprintf("%s is not online", private.GetUsername()))
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"private message\", username=\"%v\", recipient=\"%v\"", eventTimestamp, client.username, recipient.username)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_PrivateEvent{
+			PrivateEvent: &pb.StreamResponse_Private{
+				From:    client.username,
+				To:      recipient.username,
+				Message: message,
+			},
+		},
+	}
+
+	s.SendTo(recipient, response)
+	if recipient != client {
+		s.SendTo(client, response)
+	}
+}
+
+func (s *Server) handleJoin(client *Client, eventTimestamp uint64, join *pb.StreamRequest_Join) {
+	room := strings.TrimPrefix(join.GetRoom(), "#")
+	if !validName(room) {
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, "room names "+invalidNameReason)
+		return
+	}
+
+	s.mu.Lock()
+	client.room = room
+	s.mu.Unlock()
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"joined room\", username=\"%v\", room=\"%v\"", eventTimestamp, client.username, room)
+
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_JoinEvent{
+			JoinEvent: &pb.StreamResponse_Join{
+				Username: client.username,
+				Room:     room,
+			},
+		},
+	}
+
+	go s.Broadcast(response)
+}
+
+func (s *Server) handleWho(client *Client, who *pb.StreamRequest_Who) {
+	s.mu.Lock()
+	room := strings.TrimPrefix(who.GetRoom(), "#")
+	if room == "" {
+		room = client.room
+	}
+
+	members := []string{}
+	for _, c := range s.clients {
+		if c.room == room {
+			members = append(members, c.username)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Strings(members)
+
+	s.clock.Tick()
+	s.SendTo(client, &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_MembersEvent{
+			MembersEvent: &pb.StreamResponse_Members{
+				Room:      room,
+				Usernames: members,
+			},
+		},
+	})
+}
+
+// clientByName must be called with s.mu held
+func (s *Server) clientByName(username string) *Client {
+	for _, c := range s.clients {
+		if c.username == username {
+			return c
+		}
+	}
+	return nil
+}
+
+// Reject tells the client that its request was refused
+func (s *Server) Reject(client *Client, code pb.StreamResponse_Error_Code, reason string) {
+	s.clock.Tick()
+	s.SendTo(client, &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_ErrorEvent{
+			ErrorEvent: &pb.StreamResponse_Error{
+				Code:   code,
+				Reason: reason,
+			},
+		},
+	})
+}
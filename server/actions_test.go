@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "ChitChat/grpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer returns a server with everything the request handlers need, the moderation store is kept in a
+// temporary directory
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := OpenModerationStore(filepath.Join(t.TempDir(), "moderation.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		usernames:        make(map[string]bool),
+		clients:          make(map[string]*Client),
+		history:          NewHistory(100),
+		transcript:       NewTranscript(100),
+		search:           NewSearchIndex(100),
+		store:            store,
+		minMessageLength: 1,
+		validator:        DefaultValidator(),
+		filters:          NewFilterChain(),
+	}
+	s.messageLimit.Store(128)
+	return s
+}
+
+// rejection returns the code of the error the client was sent, or false if it was not sent one
+func rejection(client *Client) (pb.StreamResponse_Error_Code, bool) {
+	select {
+	case response := <-client.send:
+		if event, ok := response.Event.(*pb.StreamResponse_ErrorEvent); ok {
+			return event.ErrorEvent.Code, true
+		}
+	default:
+	}
+	return 0, false
+}
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"alice", true},
+		{"Zoë", true},
+		{"世界", true},
+		{"bob-42_x", true},
+		{strings.Repeat("a", 32), true},
+		{"", false},
+		{strings.Repeat("a", 33), false},
+		{"two words", false},
+		{"tab\there", false},
+		{"line\nbreak", false},
+		{"\u00a0alice", false},           // No-break space
+		{"red\x1b[31m", false},           // CSI escape
+		{"title\x1b]0;pwned\x07", false}, // OSC escape
+		{"esc\x1b", false},               // Lone escape
+		{"nul\x00", false},               // C0 control
+		{"del\x7f", false},               // Delete
+		{"c1\u0085", false},              // C1 control
+		{"csi\u009b31m", false},          // C1 CSI
+		{"\u202eecila", false},           // Right-to-left override
+		{"alice\u2066", false},           // Left-to-right isolate
+		{"al\u200dice", false},           // Zero width joiner
+		{"al\u200bice", false},           // Zero width space
+		{"bad\xffutf8", false},
+	}
+
+	for _, test := range tests {
+		if got := validName(test.name); got != test.want {
+			t.Errorf("validName(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHandleReact(t *testing.T) {
+	s := newTestServer(t)
+	client := recordingClient()
+	general := s.history.Add("bob", defaultRoom, "hello", 0, false)
+	other := s.history.Add("bob", "other", "hello", 0, false)
+
+	tests := []struct {
+		name     string
+		id       uint64
+		reaction string
+		// The reactions of the message afterwards
+		want string
+		code pb.StreamResponse_Error_Code
+	}{
+		{"plain", general.id, "+1", "+1", 0},
+		{"trimmed", general.id, " :) ", "+1 :)", 0},
+		{"escapes are stripped", general.id, "\x1b[31mok\x1b[0m", "+1 :) ok", 0},
+		{"toggled off", general.id, "ok", "+1 :)", 0},
+		{"emoji sequence", general.id, "\U0001f44d\U0001f3fd", "+1 :) \U0001f44d\U0001f3fd", 0},
+		{"empty", general.id, "", "+1 :) \U0001f44d\U0001f3fd", pb.StreamResponse_Error_INVALID_ARGUMENT},
+		{"only escapes", general.id, "\x1b[2J", "+1 :) \U0001f44d\U0001f3fd", pb.StreamResponse_Error_INVALID_ARGUMENT},
+		{"too long", general.id, "ninechars", "+1 :) \U0001f44d\U0001f3fd", pb.StreamResponse_Error_INVALID_ARGUMENT},
+		{"two lines", general.id, "a\nb", "+1 :) \U0001f44d\U0001f3fd", pb.StreamResponse_Error_INVALID_ARGUMENT},
+		{"unknown message", 1000, "+1", "", pb.StreamResponse_Error_NOT_FOUND},
+		{"message of another room", other.id, "+1", "", pb.StreamResponse_Error_NOT_FOUND},
+	}
+
+	for _, test := range tests {
+		s.handleReact(client, 0, &pb.StreamRequest_React{Id: test.id, Reaction: test.reaction})
+
+		code, rejected := rejection(client)
+		if test.code != 0 && (!rejected || code != test.code) {
+			t.Errorf("%s: rejected %v with %v, want %v", test.name, rejected, code, test.code)
+		}
+		if test.code == 0 && rejected {
+			t.Errorf("%s: rejected with %v", test.name, code)
+		}
+
+		s.mu.Lock()
+		stored := s.history.Get(test.id)
+		reactions := []string{}
+		if stored != nil {
+			for _, count := range stored.ReactionCounts() {
+				reactions = append(reactions, count.Reaction)
+			}
+		}
+		s.mu.Unlock()
+		if test.id == general.id && strings.Join(reactions, " ") != test.want {
+			t.Errorf("%s: reactions %q, want %q", test.name, reactions, test.want)
+		}
+	}
+
+	if len(other.ReactionCounts()) != 0 {
+		t.Errorf("the message of another room got reactions %v", other.ReactionCounts())
+	}
+}
+
+func TestGetThread(t *testing.T) {
+	s := newTestServer(t)
+	s.store.SetRole("mod", Moderator)
+	general := s.history.Add("bob", defaultRoom, "hello", 0, false)
+	reply := s.history.Add("carol", defaultRoom, "hi", general.id, false)
+	other := s.history.Add("bob", "other", "secret", 0, false)
+	s.history.Add("carol", "other", "also secret", other.id, false)
+
+	alice := recordingClient()
+	mod := &Client{username: "mod", room: defaultRoom}
+
+	tests := []struct {
+		name    string
+		client  *Client
+		id      uint64
+		replies int
+		code    codes.Code
+	}{
+		{"own room", alice, general.id, 1, codes.OK},
+		{"from a reply", alice, reply.id, 1, codes.OK},
+		{"unknown message", alice, 1000, 0, codes.NotFound},
+		{"another room", alice, other.id, 0, codes.NotFound},
+		{"moderator in another room", mod, other.id, 1, codes.OK},
+	}
+
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), clientKey{}, test.client)
+		response, err := s.GetThread(ctx, &pb.ThreadRequest{Id: test.id})
+		if code := status.Code(err); code != test.code {
+			t.Errorf("%s: got %v, want %v", test.name, code, test.code)
+			continue
+		}
+		if err == nil && len(response.Replies) != test.replies {
+			t.Errorf("%s: got %d replies, want %d", test.name, len(response.Replies), test.replies)
+		}
+	}
+}
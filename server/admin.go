@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServer implements the AdminService on top of the chat server
+type AdminServer struct {
+	pb.UnimplementedAdminServiceServer
+
+	chat *Server
+}
+
+// NewAdminServer creates the admin service, callers are authorized by the admin token in the interceptors
+func NewAdminServer(chat *Server) *AdminServer {
+	return &AdminServer{chat: chat}
+}
+
+func (a *AdminServer) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	s := a.chat
+	s.mu.Lock()
+	sessions := make([]*pb.Session, 0, len(s.clients))
+	for _, c := range s.clients {
+		sessions = append(sessions, &pb.Session{
+			Username:    c.username,
+			Room:        c.room,
+			Ip:          c.ip,
+			ConnectedAt: c.connectedAt.Unix(),
+			Streaming:   c.stream != nil,
+			Role:        string(s.store.Role(c.username)),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Username < sessions[j].Username
+	})
+
+	return &pb.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+func (a *AdminServer) Kick(ctx context.Context, req *pb.KickRequest) (*pb.KickResponse, error) {
+	s := a.chat
+	s.mu.Lock()
+	client := s.clientByName(req.Username)
+	s.mu.Unlock()
+
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "%s is not online", req.Username)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "kicked by an administrator"
+	}
+	s.Kick(client, reason)
+
+	return &pb.KickResponse{}, nil
+}
+
+func (a *AdminServer) Ban(ctx context.Context, req *pb.BanRequest) (*pb.BanResponse, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "banned by an administrator"
+	}
+
+	var kicked []string
+	var err error
+	switch target := req.Target.(type) {
+	case *pb.BanRequest_Username:
+		kicked, err = a.chat.BanUsername(target.Username, reason)
+	case *pb.BanRequest_Ip:
+		kicked, err = a.chat.BanIP(target.Ip, reason)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "either a username or an ip must be given")
+	}
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save the ban: %v", err)
+	}
+	return &pb.BanResponse{Kicked: kicked}, nil
+}
+
+func (a *AdminServer) Announce(ctx context.Context, req *pb.AnnounceRequest) (*pb.AnnounceResponse, error) {
+	if req.Message == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty announcement")
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"admin\", type=\"announcement\", message=\"%v\"", a.chat.clock.Now(), req.Message)
+	a.chat.Announce(req.Message)
+
+	return &pb.AnnounceResponse{}, nil
+}
+
+func (a *AdminServer) SetMessageLimit(ctx context.Context, req *pb.SetMessageLimitRequest) (*pb.SetMessageLimitResponse, error) {
+	if req.Limit == 0 {
+		return nil, status.Error(codes.InvalidArgument, "the message limit must be positive")
+	}
+
+	previous := a.chat.messageLimit.Swap(req.Limit)
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"admin\", type=\"message limit\", previous=\"%v\", limit=\"%v\"", a.chat.clock.Now(), previous, req.Limit)
+
+	return &pb.SetMessageLimitResponse{Previous: previous}, nil
+}
+
+func (a *AdminServer) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	s := a.chat
+	s.mu.Lock()
+	sessions := len(s.clients)
+	s.mu.Unlock()
+
+	return &pb.StatsResponse{
+		UptimeSeconds:     int64(s.stats.Uptime().Seconds()),
+		Sessions:          uint32(sessions),
+		MessagesReceived:  s.stats.received.Load(),
+		MessagesBroadcast: s.stats.broadcast.Load(),
+		MessagesDropped:   s.stats.dropped.Load(),
+		MessagesThrottled: s.stats.throttled.Load(),
+		MessageLimit:      s.messageLimit.Load(),
+		LamportTime:       s.clock.Now(),
+	}, nil
+}
@@ -0,0 +1,372 @@
+package main
+
+// The event log keeps the transcript events on disk as JSON lines, so that they survive restarts
+// A background job compacts the log by removing the events which are past the retention policy of their room
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ChitChat/utils"
+)
+
+// RetentionPolicy limits the events kept for a room, zero values mean no limit
+type RetentionPolicy struct {
+	MaxAge   Duration `json:"max_age"`
+	MaxCount int      `json:"max_count"`
+	// The size of the events of the room in the log, in bytes
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// Duration is a time.Duration which is written as e.g. "720h" in JSON
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Retention holds the default policy and the policies of rooms which differ from it
+// Events which concern every room, such as logins, fall under the default policy
+type Retention struct {
+	Default RetentionPolicy            `json:"default"`
+	Rooms   map[string]RetentionPolicy `json:"rooms"`
+}
+
+func LoadRetention(path string, defaults RetentionPolicy) (Retention, error) {
+	retention := Retention{Default: defaults}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return retention, err
+	}
+	if err := json.Unmarshal(contents, &retention); err != nil {
+		return retention, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return retention, nil
+}
+
+func (r Retention) policy(room string) RetentionPolicy {
+	if policy, exists := r.Rooms[room]; exists {
+		return policy
+	}
+	return r.Default
+}
+
+// keeper decides which events are retained, it is passed the events newest first together with their size
+type keeper struct {
+	retention Retention
+	now       time.Time
+	counts    map[string]int
+	sizes     map[string]int64
+}
+
+func (r Retention) keeper(now time.Time) *keeper {
+	return &keeper{retention: r, now: now, counts: make(map[string]int), sizes: make(map[string]int64)}
+}
+
+func (k *keeper) keep(e *TranscriptEvent, size int64) bool {
+	policy := k.retention.policy(e.Room)
+
+	if policy.MaxAge > 0 && k.now.Sub(e.Time) > time.Duration(policy.MaxAge) {
+		return false
+	}
+	if policy.MaxCount > 0 && k.counts[e.Room] >= policy.MaxCount {
+		return false
+	}
+	if policy.MaxBytes > 0 && k.sizes[e.Room]+size > policy.MaxBytes {
+		return false
+	}
+
+	k.counts[e.Room]++
+	k.sizes[e.Room] += size
+	return true
+}
+
+// Apply returns the events which are retained, the events must be ordered oldest first
+func (r Retention) Apply(events []TranscriptEvent, now time.Time) []TranscriptEvent {
+	retained, _ := r.Split(events, now)
+	return retained
+}
+
+// Split divides the events into those which are retained and those which are not, both oldest first
+func (r Retention) Split(events []TranscriptEvent, now time.Time) ([]TranscriptEvent, []TranscriptEvent) {
+	k := r.keeper(now)
+	kept := make([]bool, len(events))
+	count := 0
+	for i := len(events) - 1; i >= 0; i-- {
+		line, _ := json.Marshal(&events[i])
+		if k.keep(&events[i], int64(len(line))+1) {
+			kept[i] = true
+			count++
+		}
+	}
+
+	retained := make([]TranscriptEvent, 0, count)
+	removed := make([]TranscriptEvent, 0, len(events)-count)
+	for i := range events {
+		if kept[i] {
+			retained = append(retained, events[i])
+		} else {
+			removed = append(removed, events[i])
+		}
+	}
+	return retained, removed
+}
+
+// EventLog appends events to a file and compacts it
+type EventLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	// The size of the file, events are only ever appended at this offset
+	size int64
+
+	// Held while compacting, so that two compactions never run at once
+	compacting sync.Mutex
+}
+
+// compactSuffix is the suffix of the temporary file a compaction writes the new log to
+const compactSuffix = ".compact"
+
+// corruptSuffix is the suffix of the file complete lines which can not be parsed are moved to by a compaction
+const corruptSuffix = ".corrupt"
+
+// OpenEventLog opens the log and returns the events it contains, oldest first
+// A torn line at the end of the file, left behind by a crash during an append, is cut off. Complete lines which can
+// not be parsed are skipped but kept in the file, the next compaction moves them to the corrupt file
+func OpenEventLog(path string) (*EventLog, []TranscriptEvent, error) {
+	// A compaction which was interrupted by a crash never replaced the log, so its output is discarded
+	if err := os.Remove(path + compactSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, valid, corrupt, err := readEvents(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if len(corrupt) > 0 {
+		utils.LogAndPrint("event log %v: skipping %v corrupt lines", path, len(corrupt))
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if valid < info.Size() {
+		utils.LogAndPrint("event log %v: discarding %v bytes of a torn write", path, info.Size()-valid)
+		if err := file.Truncate(valid); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+
+	return &EventLog{path: path, file: file, size: valid}, events, nil
+}
+
+// readEvents reads the events from the start of the file
+// Returns the events, the offset following the last complete line and the complete lines which are not valid events
+func readEvents(file io.ReadSeeker) ([]TranscriptEvent, int64, [][]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, err
+	}
+
+	events := []TranscriptEvent{}
+	var corrupt [][]byte
+	var valid int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Anything after the last newline was never completely written
+			return events, valid, corrupt, nil
+		} else if err != nil {
+			return nil, 0, nil, err
+		}
+		valid += int64(len(line))
+
+		var e TranscriptEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			// The line was written completely, so it is damage rather than a torn append and the events after it
+			// are still good
+			corrupt = append(corrupt, line)
+			continue
+		}
+		events = append(events, e)
+	}
+}
+
+// quarantine appends the lines to the corrupt file next to the log, so that they can be inspected
+func quarantine(path string, lines [][]byte) error {
+	file, err := os.OpenFile(path+corruptSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(bytes.Join(lines, nil)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Append writes the event to the end of the log and syncs it to disk
+func (l *EventLog) Append(e TranscriptEvent) error {
+	line, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.WriteAt(line, l.size); err != nil {
+		// Whatever part of the line made it to disk is overwritten by the next append
+		return err
+	}
+	l.size += int64(len(line))
+	return l.file.Sync()
+}
+
+// Compact rewrites the log without the events which are past their retention
+// Appends may continue while the log is compacted. The events appended meanwhile are copied over before the
+// new log replaces the old one, and the log is replaced with a rename so that a crash leaves one of them intact
+// Returns the number of events kept and the events which were removed
+func (l *EventLog) Compact(retention Retention) (int, []TranscriptEvent, error) {
+	l.compacting.Lock()
+	defer l.compacting.Unlock()
+
+	// Only the events up to this offset are compacted, later ones are copied as they are
+	l.mu.Lock()
+	end := l.size
+	l.mu.Unlock()
+
+	events, _, corrupt, err := readEvents(io.NewSectionReader(l.file, 0, end))
+	if err != nil {
+		return 0, nil, err
+	}
+	retained, removed := retention.Split(events, time.Now())
+
+	tmp, err := os.OpenFile(l.path+compactSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for i := range retained {
+		if err := encoder.Encode(&retained[i]); err != nil {
+			return 0, nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Copy the events appended since the compaction started
+	appended := make([]byte, l.size-end)
+	if _, err := l.file.ReadAt(appended, end); err != nil && !errors.Is(err, io.EOF) {
+		return 0, nil, err
+	}
+	if _, err := tmp.Write(appended); err != nil {
+		return 0, nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, nil, err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The corrupt lines are saved before the log without them replaces the old one
+	if len(corrupt) > 0 {
+		if err := quarantine(l.path, corrupt); err != nil {
+			return 0, nil, err
+		}
+		utils.LogAndPrint("event log %v: moved %v corrupt lines to %v", l.path, len(corrupt), l.path+corruptSuffix)
+	}
+
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return 0, nil, err
+	}
+	syncDir(filepath.Dir(l.path))
+
+	l.file.Close()
+	l.file, l.size, tmp = tmp, info.Size(), nil
+
+	return len(retained) + bytes.Count(appended, []byte{'\n'}), removed, nil
+}
+
+// syncDir makes a rename in the directory durable, this is not supported on every platform
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// compactEvery compacts the event log and the transcript at the interval until the server exits
+func (s *Server) compactEvery(interval time.Duration, retention Retention) {
+	for range time.Tick(interval) {
+		kept, removed, err := s.eventLog.Compact(retention)
+		if err != nil {
+			utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"compaction failed\", error=\"%v\"", s.clock.Now(), err)
+			continue
+		}
+
+		s.transcript.Retain(retention)
+		s.search.Expire(removed)
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"compaction\", kept=\"%v\", removed=\"%v\"", s.clock.Now(), kept, len(removed))
+	}
+}
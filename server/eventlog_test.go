@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEvent(id uint64) TranscriptEvent {
+	return TranscriptEvent{Lamport: id, Time: time.Now(), Kind: "message", Room: "general", Username: "alice", Message: fmt.Sprintf("message %d", id), ID: id}
+}
+
+// openLog opens the log at the path, failing the test on errors
+func openLog(t *testing.T, path string) (*EventLog, []TranscriptEvent) {
+	t.Helper()
+	log, events, err := OpenEventLog(path)
+	if err != nil {
+		t.Fatalf("OpenEventLog: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return log, events
+}
+
+func appendEvents(t *testing.T, log *EventLog, from uint64, to uint64) {
+	t.Helper()
+	for id := from; id <= to; id++ {
+		if err := log.Append(testEvent(id)); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+func checkIDs(t *testing.T, events []TranscriptEvent, want ...uint64) {
+	t.Helper()
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i := range want {
+		if events[i].ID != want[i] {
+			t.Fatalf("event %d has ID %d, want %d", i, events[i].ID, want[i])
+		}
+	}
+}
+
+func TestEventLogReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, events := openLog(t, path)
+	checkIDs(t, events)
+	appendEvents(t, log, 1, 3)
+	log.Close()
+
+	_, events = openLog(t, path)
+	checkIDs(t, events, 1, 2, 3)
+}
+
+func TestEventLogInterruptedCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, _ := openLog(t, path)
+	appendEvents(t, log, 1, 3)
+	log.Close()
+
+	// A crash during a compaction leaves its output behind, possibly incomplete
+	if err := os.WriteFile(path+compactSuffix, []byte(`{"lamport":1,"kind":"mess`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, events := openLog(t, path)
+	checkIDs(t, events, 1, 2, 3)
+	if _, err := os.Stat(path + compactSuffix); !os.IsNotExist(err) {
+		t.Fatalf("the output of the interrupted compaction was not removed: %v", err)
+	}
+}
+
+func TestEventLogTornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, _ := openLog(t, path)
+	appendEvents(t, log, 1, 2)
+	log.Close()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"lamport":3,"kind":"message","mess`)
+	file.Close()
+
+	log, events := openLog(t, path)
+	checkIDs(t, events, 1, 2)
+
+	// The next append goes where the torn line was
+	appendEvents(t, log, 3, 3)
+	log.Close()
+
+	_, events = openLog(t, path)
+	checkIDs(t, events, 1, 2, 3)
+}
+
+func TestEventLogCorruptMiddleLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, _ := openLog(t, path)
+	appendEvents(t, log, 1, 1)
+	log.Close()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("not an event\n")
+	file.Close()
+
+	log, _ = openLog(t, path)
+	appendEvents(t, log, 2, 3)
+	log.Close()
+
+	// The events after the corrupt line survive reopening
+	log, events := openLog(t, path)
+	checkIDs(t, events, 1, 2, 3)
+
+	// and compacting, which moves the corrupt line aside
+	if _, _, err := log.Compact(Retention{}); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	log.Close()
+
+	_, events = openLog(t, path)
+	checkIDs(t, events, 1, 2, 3)
+
+	corrupt, err := os.ReadFile(path + corruptSuffix)
+	if err != nil {
+		t.Fatalf("the corrupt line was not quarantined: %v", err)
+	}
+	if string(corrupt) != "not an event\n" {
+		t.Fatalf("quarantined %q", corrupt)
+	}
+}
+
+func TestEventLogCompactRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, _ := openLog(t, path)
+	appendEvents(t, log, 1, 5)
+
+	kept, removed, err := log.Compact(Retention{Default: RetentionPolicy{MaxCount: 2}})
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if kept != 2 {
+		t.Fatalf("kept %d events, want 2", kept)
+	}
+	checkIDs(t, removed, 1, 2, 3)
+
+	// Appends continue on the compacted log
+	appendEvents(t, log, 6, 6)
+	log.Close()
+
+	_, events := openLog(t, path)
+	checkIDs(t, events, 4, 5, 6)
+}
+
+func TestEventLogAppendDuringCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	log, _ := openLog(t, path)
+	appendEvents(t, log, 1, 100)
+
+	const total = 1000
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for id := uint64(101); id <= total; id++ {
+			if err := log.Append(testEvent(id)); err != nil {
+				t.Errorf("Append: %v", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		if _, _, err := log.Compact(Retention{}); err != nil {
+			t.Fatalf("Compact: %v", err)
+		}
+	}
+	wg.Wait()
+	log.Close()
+
+	_, events := openLog(t, path)
+	want := make([]uint64, total)
+	for i := range want {
+		want[i] = uint64(i + 1)
+	}
+	checkIDs(t, events, want...)
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(contents), "\n") {
+		t.Fatal("the log does not end with a complete line")
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+// Content filters see every chat message after it has been validated and before it is broadcast
+// They are configured from a JSON file, see filters.example.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+)
+
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	Rewrite
+	Reject
+)
+
+// FilterMessage is the message a filter is asked about
+type FilterMessage struct {
+	Author string
+	Room   string
+	Text   string
+}
+
+// FilterResult tells the chain what to do with a message
+// Text is only used when rewriting and Reason only when rejecting
+type FilterResult struct {
+	Verdict Verdict
+	Text    string
+	Reason  string
+}
+
+type Filter interface {
+	Name() string
+	Filter(msg FilterMessage) FilterResult
+}
+
+// FilterChain runs the filters in order, each filter sees the message as rewritten by the filters before it
+type FilterChain struct {
+	filters []Filter
+}
+
+func NewFilterChain(filters ...Filter) *FilterChain {
+	return &FilterChain{filters: filters}
+}
+
+// Run returns the final text of the message, or the rejection together with the name of the filter which rejected it
+func (c *FilterChain) Run(msg FilterMessage) (string, *FilterResult, string) {
+	for _, filter := range c.filters {
+		result := filter.Filter(msg)
+		switch result.Verdict {
+		case Rewrite:
+			msg.Text = result.Text
+		case Reject:
+			return "", &result, filter.Name()
+		}
+	}
+	return msg.Text, nil, ""
+}
+
+func (c *FilterChain) Len() int {
+	return len(c.filters)
+}
+
+// mask replaces every character of the match with an asterisk
+func mask(match string) string {
+	return strings.Repeat("*", utf8.RuneCountInString(match))
+}
+
+// WordFilter masks or rejects messages containing any of the words, ignoring case
+type WordFilter struct {
+	pattern *regexp.Regexp
+	reject  bool
+}
+
+func NewWordFilter(words []string, reject bool) (*WordFilter, error) {
+	if len(words) == 0 {
+		return nil, fmt.Errorf("the word filter needs at least one word")
+	}
+
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+
+	pattern, err := regexp.Compile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	if err != nil {
+		return nil, err
+	}
+	return &WordFilter{pattern: pattern, reject: reject}, nil
+}
+
+func (f *WordFilter) Name() string {
+	return "words"
+}
+
+func (f *WordFilter) Filter(msg FilterMessage) FilterResult {
+	if !f.pattern.MatchString(msg.Text) {
+		return FilterResult{Verdict: Allow}
+	}
+	if f.reject {
+		return FilterResult{Verdict: Reject, Reason: "your message contains a blocked word"}
+	}
+	return FilterResult{Verdict: Rewrite, Text: f.pattern.ReplaceAllStringFunc(msg.Text, mask)}
+}
+
+// RedactFilter replaces everything matching one of the patterns, e.g. API keys and passwords
+type RedactFilter struct {
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+func NewRedactFilter(patterns []string, replacement string) (*RedactFilter, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("the redact filter needs at least one pattern")
+	}
+
+	filter := &RedactFilter{replacement: replacement}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, re)
+	}
+
+	if filter.replacement == "" {
+		filter.replacement = "[redacted]"
+	}
+	return filter, nil
+}
+
+func (f *RedactFilter) Name() string {
+	return "redact"
+}
+
+func (f *RedactFilter) Filter(msg FilterMessage) FilterResult {
+	text := msg.Text
+	for _, pattern := range f.patterns {
+		text = pattern.ReplaceAllLiteralString(text, f.replacement)
+	}
+
+	if text == msg.Text {
+		return FilterResult{Verdict: Allow}
+	}
+	return FilterResult{Verdict: Rewrite, Text: text}
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)[^\s]+`)
+
+// LinkFilter removes or rejects links, except for links to the allowed hosts and their subdomains
+type LinkFilter struct {
+	allowed []string
+	reject  bool
+}
+
+func NewLinkFilter(allowed []string, reject bool) *LinkFilter {
+	hosts := make([]string, len(allowed))
+	for i, host := range allowed {
+		hosts[i] = strings.ToLower(host)
+	}
+	return &LinkFilter{allowed: hosts, reject: reject}
+}
+
+func (f *LinkFilter) Name() string {
+	return "links"
+}
+
+func (f *LinkFilter) isAllowed(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range f.allowed {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *LinkFilter) Filter(msg FilterMessage) FilterResult {
+	blocked := false
+	text := linkPattern.ReplaceAllStringFunc(msg.Text, func(link string) string {
+		if f.isAllowed(link) {
+			return link
+		}
+		blocked = true
+		return "[link removed]"
+	})
+
+	if !blocked {
+		return FilterResult{Verdict: Allow}
+	}
+	if f.reject {
+		return FilterResult{Verdict: Reject, Reason: "links are not allowed here"}
+	}
+	return FilterResult{Verdict: Rewrite, Text: text}
+}
+
+// filterConfig is one entry of the filter file, which fields are used depends on the type
+type filterConfig struct {
+	Type string `json:"type"`
+	// Either "rewrite" (the default) or "reject"
+	Action string `json:"action"`
+
+	Words       []string `json:"words"`
+	Patterns    []string `json:"patterns"`
+	Replacement string   `json:"replacement"`
+	Allow       []string `json:"allow"`
+}
+
+// filterFields lists the fields every type of filter supports besides its type
+var filterFields = map[string][]string{
+	"words":  {"action", "words"},
+	"redact": {"patterns", "replacement"},
+	"links":  {"action", "allow"},
+}
+
+// checkFilterFields returns an error for a field the type of filter does not support, rather than ignoring it
+func checkFilterFields(entry json.RawMessage) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return err
+	}
+
+	var filterType string
+	json.Unmarshal(fields["type"], &filterType)
+	supported, known := filterFields[filterType]
+	if !known {
+		return fmt.Errorf("unknown type %q", filterType)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if name != "type" && !slices.Contains(supported, name) {
+			return fmt.Errorf("the %s filter does not support %q", filterType, name)
+		}
+	}
+	return nil
+}
+
+// LoadFilterChain reads the filters from a JSON file and chains them in the order they are listed
+func LoadFilterChain(path string) (*FilterChain, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Filters []json.RawMessage `json:"filters"`
+	}
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, err
+	}
+
+	chain := NewFilterChain()
+	for i, entry := range config.Filters {
+		if err := checkFilterFields(entry); err != nil {
+			return nil, fmt.Errorf("filter %d: %v", i, err)
+		}
+		var fc filterConfig
+		if err := json.Unmarshal(entry, &fc); err != nil {
+			return nil, fmt.Errorf("filter %d: %v", i, err)
+		}
+
+		if fc.Action != "" && fc.Action != "rewrite" && fc.Action != "reject" {
+			return nil, fmt.Errorf("filter %d: unknown action %q", i, fc.Action)
+		}
+		reject := fc.Action == "reject"
+
+		var filter Filter
+		switch fc.Type {
+		case "words":
+			filter, err = NewWordFilter(fc.Words, reject)
+		case "redact":
+			filter, err = NewRedactFilter(fc.Patterns, fc.Replacement)
+		case "links":
+			filter = NewLinkFilter(fc.Allow, reject)
+		default:
+			err = fmt.Errorf("unknown type %q", fc.Type)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("filter %d: %v", i, err)
+		}
+		chain.filters = append(chain.filters, filter)
+	}
+
+	return chain, nil
+}
+
+// filterMessage runs the message through the content filters of the server
+// Rejected messages are reported back to the client and "" and false are returned
+func (s *Server) filterMessage(client *Client, eventTimestamp uint64, message string) (string, bool) {
+	text, rejection, name := s.filters.Run(FilterMessage{Author: client.username, Room: client.room, Text: message})
+	if rejection != nil {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"filtered message\", username=\"%v\", filter=\"%v\", reason=\"%v\"", eventTimestamp, client.username, name, rejection.Reason)
+		s.Reject(client, pb.StreamResponse_Error_FILTERED, rejection.Reason)
+		return "", false
+	}
+
+	if text != message {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"rewritten message\", username=\"%v\"", eventTimestamp, client.username)
+	}
+	return text, true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// servedServices are reported by the health service, "" is the status of the server as a whole
+var servedServices = []string{
+	"",
+	pb.ChitChatService_ServiceDesc.ServiceName,
+	pb.AdminService_ServiceDesc.ServiceName,
+}
+
+func (s *Server) setHealth(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range servedServices {
+		s.health.SetServingStatus(service, status)
+	}
+}
+
+// Drain stops the server from accepting new sessions, the health status becomes NOT_SERVING
+// Connected clients may keep chatting until they are disconnected
+func (s *Server) Drain() {
+	if s.draining.Swap(true) {
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"draining\"", s.clock.Now())
+	s.setHealth(healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// DisconnectAll kicks every connected client
+func (s *Server) DisconnectAll(reason string) {
+	s.mu.Lock()
+	clients := make([]*Client, 0, len(s.clients))
+	for _, c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		s.Kick(c, reason)
+	}
+}
+
+// shutdownOnSignal drains the server on SIGINT or SIGTERM, gives the clients the grace period to finish
+// and then stops the grpc server. A second signal stops the server right away
+func (s *Server) shutdownOnSignal(grpcServer *grpc.Server, grace time.Duration) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	sig := <-signals
+	utils.LogAndPrint("received %v, shutting down within %v", sig, grace)
+
+	s.Drain()
+	if grace > 0 {
+		s.Announce("The server is shutting down in " + grace.String())
+		select {
+		case <-time.After(grace):
+		case <-signals:
+			grpcServer.Stop()
+			return
+		}
+	}
+
+	s.DisconnectAll("the server is shutting down")
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-signals:
+		grpcServer.Stop()
+	case <-time.After(5 * time.Second):
+		grpcServer.Stop()
+	}
+}
+
+// registerHealth registers the health and reflection services and marks the server as serving
+func (s *Server) registerHealth(grpcServer *grpc.Server) {
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, s.health)
+	reflection.Register(grpcServer)
+	s.setHealth(healthpb.HealthCheckResponse_SERVING)
+}
@@ -0,0 +1,163 @@
+package main
+
+import pb "ChitChat/grpc"
+
+// StoredMessage is the server's record of a chat message that was broadcast
+type StoredMessage struct {
+	id      uint64
+	author  string
+	room    string
+	message string
+	emote   bool
+	deleted bool
+
+	// Threads are flat, a reply always refers to the root message of its thread
+	parentID uint64
+	replies  []uint64
+
+	// Maps each reaction to the set of users who reacted with it
+	reactions     map[string]map[string]bool
+	reactionOrder []string
+}
+
+// History keeps the most recent chat messages so that they can be referred to by their ID
+// Once the capacity is reached the oldest message is forgotten
+type History struct {
+	capacity int
+	nextID   uint64
+	order    []uint64
+	messages map[uint64]*StoredMessage
+}
+
+func NewHistory(capacity int) *History {
+	return &History{
+		capacity: capacity,
+		nextID:   1,
+		order:    make([]uint64, 0, capacity),
+		messages: make(map[uint64]*StoredMessage),
+	}
+}
+
+// ResumeAfter makes the IDs handed out continue after the given ID, so that they stay unique across restarts
+func (h *History) ResumeAfter(id uint64) {
+	h.nextID = max(h.nextID, id+1)
+}
+
+// Add assigns a unique ID to the message and stores it
+// The parent must be a known root message, or 0 if the message does not belong to a thread
+func (h *History) Add(author string, room string, message string, parentID uint64, emote bool) *StoredMessage {
+	stored := &StoredMessage{
+		id:       h.nextID,
+		author:   author,
+		room:     room,
+		message:  message,
+		emote:    emote,
+		parentID: parentID,
+	}
+	h.nextID++
+
+	if len(h.order) == h.capacity {
+		delete(h.messages, h.order[0])
+		h.order = h.order[1:]
+	}
+
+	h.order = append(h.order, stored.id)
+	h.messages[stored.id] = stored
+
+	if parent := h.Get(parentID); parent != nil {
+		parent.replies = append(parent.replies, stored.id)
+	}
+
+	return stored
+}
+
+// Get returns the message with the given ID, or nil if it is unknown or deleted
+func (h *History) Get(id uint64) *StoredMessage {
+	msg, exists := h.messages[id]
+	if !exists || msg.deleted {
+		return nil
+	}
+	return msg
+}
+
+// RenameAuthor transfers the ownership of a user's messages to their new username
+func (h *History) RenameAuthor(old string, username string) {
+	for _, msg := range h.messages {
+		if msg.author == old {
+			msg.author = username
+		}
+	}
+}
+
+// ThreadRoot returns the message a reply to the given message should be attached to
+func (h *History) ThreadRoot(id uint64) *StoredMessage {
+	msg := h.Get(id)
+	if msg != nil && msg.parentID != 0 {
+		return h.Get(msg.parentID)
+	}
+	return msg
+}
+
+// Replies returns the replies of a message which are still remembered, oldest first
+func (h *History) Replies(msg *StoredMessage) []*StoredMessage {
+	replies := make([]*StoredMessage, 0, len(msg.replies))
+	for _, id := range msg.replies {
+		if reply := h.Get(id); reply != nil {
+			replies = append(replies, reply)
+		}
+	}
+	return replies
+}
+
+func (msg *StoredMessage) ToProto() *pb.StreamResponse_Message {
+	return &pb.StreamResponse_Message{
+		Username: msg.author,
+		Message:  msg.message,
+		Id:       msg.id,
+		ParentId: msg.parentID,
+		Emote:    msg.emote,
+		Room:     msg.room,
+	}
+}
+
+// ToggleReaction adds the user's reaction to the message, or removes it if the user already reacted with it
+func (msg *StoredMessage) ToggleReaction(username string, reaction string) {
+	if msg.reactions == nil {
+		msg.reactions = make(map[string]map[string]bool)
+	}
+
+	users, exists := msg.reactions[reaction]
+	if !exists {
+		users = make(map[string]bool)
+		msg.reactions[reaction] = users
+		msg.reactionOrder = append(msg.reactionOrder, reaction)
+	}
+
+	if users[username] {
+		delete(users, username)
+	} else {
+		users[username] = true
+	}
+
+	if len(users) == 0 {
+		delete(msg.reactions, reaction)
+		for i, r := range msg.reactionOrder {
+			if r == reaction {
+				msg.reactionOrder = append(msg.reactionOrder[:i], msg.reactionOrder[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// ReactionCounts returns the number of users per reaction in the order the reactions were first used
+func (msg *StoredMessage) ReactionCounts() []*pb.StreamResponse_ReactionCount {
+	counts := make([]*pb.StreamResponse_ReactionCount, 0, len(msg.reactionOrder))
+	for _, reaction := range msg.reactionOrder {
+		counts = append(counts, &pb.StreamResponse_ReactionCount{
+			Reaction: reaction,
+			Count:    uint32(len(msg.reactions[reaction])),
+		})
+	}
+	return counts
+}
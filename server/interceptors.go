@@ -0,0 +1,225 @@
+package main
+
+// Interceptors which wrap every RPC of the server, so that the handlers only deal with chat logic
+// The chain is: metrics and logging, then panic recovery, then authentication
+
+import (
+	"context"
+	"crypto/subtle"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type clientKey struct{}
+
+// clientFromContext returns the client authenticated by the auth interceptor
+func clientFromContext(ctx context.Context) *Client {
+	client, _ := ctx.Value(clientKey{}).(*Client)
+	return client
+}
+
+// MethodStats are the metrics collected for a single RPC method
+type MethodStats struct {
+	Calls  uint64
+	Errors uint64
+	// The total time spent in the method, for streams this is how long they were open
+	Latency time.Duration
+	// Counts the calls by latency, Buckets[i] counts the calls which took at most LatencyBuckets[i]
+	Buckets []uint64
+}
+
+// LatencyBuckets are the upper bounds of the latency histogram of every method
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	25 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// RPCMetrics collects the number of calls, errors and latencies of every RPC method
+type RPCMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*MethodStats
+}
+
+func NewRPCMetrics() *RPCMetrics {
+	return &RPCMetrics{methods: make(map[string]*MethodStats)}
+}
+
+func (m *RPCMetrics) observe(method string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, exists := m.methods[method]
+	if !exists {
+		stats = &MethodStats{Buckets: make([]uint64, len(LatencyBuckets))}
+		m.methods[method] = stats
+	}
+
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.Latency += latency
+	for i, bound := range LatencyBuckets {
+		if latency <= bound {
+			stats.Buckets[i]++
+		}
+	}
+}
+
+// Snapshot returns a copy of the metrics sorted by method name
+func (m *RPCMetrics) Snapshot() ([]string, []MethodStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	methods := make([]string, 0, len(m.methods))
+	for method := range m.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	stats := make([]MethodStats, len(methods))
+	for i, method := range methods {
+		stats[i] = *m.methods[method]
+		stats[i].Buckets = append([]uint64(nil), stats[i].Buckets...)
+	}
+	return methods, stats
+}
+
+// Interceptors holds what the interceptors need to authenticate calls and record metrics
+type Interceptors struct {
+	chat       *Server
+	adminToken string
+	metrics    *RPCMetrics
+}
+
+func NewInterceptors(chat *Server, adminToken string) *Interceptors {
+	return &Interceptors{chat: chat, adminToken: adminToken, metrics: NewRPCMetrics()}
+}
+
+// ServerOptions returns the options which install the interceptor chains on a grpc server
+func (i *Interceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.logUnary, i.recoverUnary, i.authUnary),
+		grpc.ChainStreamInterceptor(i.logStream, i.recoverStream, i.authStream),
+	}
+}
+
+// authStream wraps a server stream so that handlers see the context carrying the authenticated client
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (a *authStream) Context() context.Context {
+	return a.ctx
+}
+
+// authenticate checks the credentials required by the method and returns the context the handler should use
+// Chat calls need the session token handed out by Connect, admin calls need the admin token
+// Methods of other services, such as health checks, are left alone
+func (i *Interceptors) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	switch {
+	case fullMethod == pb.ChitChatService_Connect_FullMethodName:
+		return ctx, nil
+	case strings.HasPrefix(fullMethod, "/"+pb.ChitChatService_ServiceDesc.ServiceName+"/"):
+		client, err := i.chat.AuthClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return context.WithValue(ctx, clientKey{}, client), nil
+	case strings.HasPrefix(fullMethod, "/"+pb.AdminService_ServiceDesc.ServiceName+"/"):
+		return ctx, i.authorizeAdmin(ctx)
+	}
+	return ctx, nil
+}
+
+// authorizeAdmin checks that the caller presented the admin token
+func (i *Interceptors) authorizeAdmin(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	tokens := md["authorization"]
+	if len(tokens) == 0 {
+		return status.Error(codes.Unauthenticated, "missing admin token")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(i.adminToken)) != 1 {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"admin\", type=\"refused admin request\", ip=\"%v\"", i.chat.clock.Now(), peerIP(ctx))
+		return status.Error(codes.PermissionDenied, "invalid admin token")
+	}
+
+	return nil
+}
+
+func (i *Interceptors) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *Interceptors) authStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := i.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authStream{ServerStream: stream, ctx: ctx})
+}
+
+// recovered turns a panic in a handler into an Internal error instead of crashing the server
+func (i *Interceptors) recovered(fullMethod string, err *error) {
+	if r := recover(); r != nil {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"panic\", method=\"%v\", error=\"%v\", stack=\"%s\"", i.chat.clock.Now(), fullMethod, r, debug.Stack())
+		*err = status.Error(codes.Internal, "internal server error")
+	}
+}
+
+func (i *Interceptors) recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer i.recovered(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+func (i *Interceptors) recoverStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer i.recovered(info.FullMethod, &err)
+	return handler(srv, stream)
+}
+
+func (i *Interceptors) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	latency := time.Since(start)
+
+	i.metrics.observe(info.FullMethod, latency, err)
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"rpc\", type=\"unary\", method=\"%v\", ip=\"%v\", code=\"%v\", latency=\"%v\"", i.chat.clock.Now(), info.FullMethod, peerIP(ctx), status.Code(err), latency)
+	return resp, err
+}
+
+func (i *Interceptors) logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"rpc\", type=\"stream opened\", method=\"%v\", ip=\"%v\"", i.chat.clock.Now(), info.FullMethod, peerIP(stream.Context()))
+
+	start := time.Now()
+	err := handler(srv, stream)
+	latency := time.Since(start)
+
+	i.metrics.observe(info.FullMethod, latency, err)
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"rpc\", type=\"stream closed\", method=\"%v\", ip=\"%v\", code=\"%v\", duration=\"%v\"", i.chat.clock.Now(), info.FullMethod, peerIP(stream.Context()), status.Code(err), latency)
+	return err
+}
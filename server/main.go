@@ -4,17 +4,22 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	pb "ChitChat/grpc"
 	clocks "ChitChat/logical_clocks"
 	"ChitChat/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
@@ -30,20 +35,65 @@ func GenerateSecureToken() string {
 }
 
 type Client struct {
-	username string
-	token    string
-	stream   pb.ChitChatService_StreamServer
-	send     chan *pb.StreamResponse
+	username    string
+	room        string
+	token       string
+	ip          string
+	connectedAt time.Time
+	stream      pb.ChitChatService_StreamServer
+	send        chan *pb.StreamResponse
+
+	// Receives the final event for the client when it is kicked from the server
+	kick chan *pb.StreamResponse
+
+	limiter *TokenBucket
 }
 
 type Server struct {
 	pb.UnimplementedChitChatServiceServer
 
 	clock clocks.LamportClock
+	stats Stats
+
+	// The maximum length of a chat message in runes, can be changed at runtime through the admin service
+	messageLimit     atomic.Uint32
+	minMessageLength int
+	maxMessageLines  int
+	validator        *Validator
+	filters          *FilterChain
+
+	rateLimit RateLimit
+
+	// Accounts, mutes and bans which persist across restarts
+	store *ModerationStore
+
+	// Reports whether new sessions are accepted, see Drain
+	health   *health.Server
+	draining atomic.Bool
 
 	mu        sync.Mutex
 	usernames map[string]bool
 	clients   map[string]*Client
+	history   *History
+
+	// Broadcast events kept for exports, and persisted in the event log if it is enabled
+	transcript *Transcript
+	eventLog   *EventLog
+	search     *SearchIndex
+}
+
+// peerIP returns the IP address of the caller without the port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
 }
 
 func (s *Server) Connect(ctx context.Context, req *pb.ConnectRequest) (*pb.ConnectResponse, error) {
@@ -52,8 +102,42 @@ func (s *Server) Connect(ctx context.Context, req *pb.ConnectRequest) (*pb.Conne
 
 	peer, _ := peer.FromContext(ctx)
 
+	// Checked first, so that the name is safe to log
+	if !validName(req.Username) {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused login request\", ip=\"%s\", username=%q, reason=\"invalid username\"", eventTimestamp, peer.Addr.String(), req.Username)
+		return nil, status.Error(codes.InvalidArgument, "usernames "+invalidNameReason)
+	}
+
 	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"login request\", ip=\"%s\", username=\"%s\"", eventTimestamp, peer.Addr.String(), req.Username)
 
+	if s.draining.Load() {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused login request\", ip=\"%s\", username=\"%s\", reason=\"draining\"", eventTimestamp, peer.Addr.String(), req.Username)
+		return nil, status.Error(codes.Unavailable, "the server is shutting down")
+	}
+
+	// The credentials are checked before taking the lock, hashing the password takes a while on purpose
+	ip := peerIP(ctx)
+	if s.store.IsBanned(req.Username, ip) {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused login request\", ip=\"%s\", username=\"%s\", reason=\"banned\"", eventTimestamp, peer.Addr.String(), req.Username)
+		return nil, status.Error(codes.PermissionDenied, "you are banned from this server")
+	}
+
+	if err := s.store.Authenticate(req.Username, req.Password); err != nil {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused login request\", ip=\"%s\", username=\"%s\", reason=\"%v\"", eventTimestamp, peer.Addr.String(), req.Username, err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	client := &Client{
+		username:    req.Username,
+		room:        defaultRoom,
+		token:       GenerateSecureToken(),
+		ip:          ip,
+		connectedAt: time.Now(),
+		stream:      nil,
+		kick:        make(chan *pb.StreamResponse, 1),
+		limiter:     NewTokenBucket(s.rateLimit),
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -63,12 +147,6 @@ func (s *Server) Connect(ctx context.Context, req *pb.ConnectRequest) (*pb.Conne
 		return nil, err
 	}
 
-	client := &Client{
-		username: req.Username,
-		token:    GenerateSecureToken(),
-		stream:   nil,
-	}
-
 	s.usernames[client.username] = true
 	s.clients[client.token] = client
 
@@ -86,10 +164,11 @@ func (s *Server) Connect(ctx context.Context, req *pb.ConnectRequest) (*pb.Conne
 
 	go s.Broadcast(response)
 
-	return &pb.ConnectResponse{Timestamp: eventTimestamp, Token: client.token}, nil
+	return &pb.ConnectResponse{Timestamp: eventTimestamp, Token: client.token, Room: client.room}, nil
 
 }
 
+// AuthClient looks up the client by the session token in the metadata, it is called by the auth interceptor
 func (s *Server) AuthClient(ctx context.Context) (*Client, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 
@@ -105,21 +184,48 @@ func (s *Server) AuthClient(ctx context.Context) (*Client, error) {
 	s.mu.Lock()
 	token := tokens[0]
 	client, exists := s.clients[token]
+	s.mu.Unlock()
 	if !exists {
 		return nil, status.Error(codes.Unauthenticated, "invalid auth token")
 	}
-	s.mu.Unlock()
 
-	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"auth sucess\", username=\"%s\"", s.clock.Now(), client.username)
 	return client, nil
 }
 
+func (s *Server) GetThread(ctx context.Context, req *pb.ThreadRequest) (*pb.ThreadResponse, error) {
+	client := clientFromContext(ctx)
+	eventTimestamp := s.clock.Sync(clocks.From(req.Timestamp))
+	moderator := s.store.Role(client.username).rank() >= Moderator.rank()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Threads of other rooms are only shown to moderators, to members they do not exist
+	parent := s.history.ThreadRoot(req.Id)
+	if parent == nil || (parent.room != client.room && !moderator) {
+		return nil, status.Error(codes.NotFound, "unknown message")
+	}
+
+	response := &pb.ThreadResponse{Timestamp: eventTimestamp, Parent: parent.ToProto()}
+	for _, reply := range s.history.Replies(parent) {
+		response.Replies = append(response.Replies, reply.ToProto())
+	}
+
+	return response, nil
+}
+
+var errKicked = status.Error(codes.PermissionDenied, "kicked from the server")
+
 func (c *Client) ClientBroadcasterHandler(ctx context.Context, errorChan chan error) {
 	for {
 		select {
 		case <-ctx.Done():
 			errorChan <- ctx.Err()
 			return
+		case msg := <-c.kick:
+			c.stream.Send(msg)
+			errorChan <- errKicked
+			return
 		case msg := <-c.send:
 			if err := c.stream.Send(msg); err != nil {
 				errorChan <- err
@@ -129,65 +235,113 @@ func (c *Client) ClientBroadcasterHandler(ctx context.Context, errorChan chan er
 	}
 }
 
+// receiver forwards the requests of the client to the Stream handler, so that it can be interrupted while waiting
+func receiver(stream pb.ChitChatService_StreamServer, requests chan *pb.StreamRequest, errorChan chan error) {
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			errorChan <- err
+			return
+		}
+
+		select {
+		case requests <- in:
+		case <-stream.Context().Done():
+			return
+		}
+	}
+}
+
 // Stream is multi-threaded by default
 // Whenever a client calls Stream() grpc spawns a new thread through this method
 func (s *Server) Stream(stream pb.ChitChatService_StreamServer) error {
-	// Auth the user
-	peer, _ := peer.FromContext(stream.Context())
-	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"stream request\", ip=\"%v\"", s.clock.Now(), peer.Addr.String())
-	client, err := s.AuthClient(stream.Context())
-	if err != nil {
-		return err
-	}
+	// The client has already been authenticated by the interceptors
+	client := clientFromContext(stream.Context())
 
 	// Update the stream of the client
 	// Create channel for communication
+	s.mu.Lock()
 	client.stream = stream
 	client.send = make(chan *pb.StreamResponse, 32)
+	s.mu.Unlock()
 
 	// Spawns goroutine to handle broadcasting to the client
 	errorChan := make(chan error, 1)
 	go client.ClientBroadcasterHandler(stream.Context(), errorChan)
 
+	requests := make(chan *pb.StreamRequest)
+	recvErrorChan := make(chan error, 1)
+	go receiver(stream, requests, recvErrorChan)
+
 	for {
+		var in *pb.StreamRequest
+
 		select {
-		case <-errorChan:
+		case err := <-errorChan:
 			s.DisconnectClient(client)
+			if err == errKicked {
+				return err
+			}
 			return nil
-		default:
+		case err := <-recvErrorChan:
+			if err == io.EOF { // If the client called CloseSend()
+				s.DisconnectClient(client)
+				return nil
+			}
+			return err
+		case in = <-requests:
 		}
+		s.stats.received.Add(1)
 
-		in, err := stream.Recv()
-		if err == io.EOF { // If the client called CloseSend()
-			s.DisconnectClient(client)
-			return nil
+		if !s.throttle(client) {
+			continue
 		}
 
-		if err != nil {
-			return err
+		// Bans and mutes may have been issued while the client was connected
+		if s.store.IsBanned(client.username, client.ip) {
+			s.Kick(client, "banned from the server")
+			continue
 		}
-		eventTimestamp := s.clock.Sync(clocks.From(in.Timestamp))
-
-		message := in.GetMessage()
-
-		if len(message) > 128 {
-			utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"message too long\", username=\"%v\"", eventTimestamp, client.username)
+		if mutedFor := s.store.MutedFor(client.username); mutedFor > 0 && speaks(in) {
+			utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"muted\", username=\"%v\"", s.clock.Now(), client.username)
+			s.Reject(client, pb.StreamResponse_Error_MUTED, fmt.Sprintf("you are muted for another %v", mutedFor.Round(time.Second)))
 			continue
 		}
 
-		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"received message\", username=\"%v\", message=\"%v\"", eventTimestamp, client.username, message)
-		s.clock.Tick()
-		response := &pb.StreamResponse{
-			Timestamp: s.clock.Now(),
-			Event: &pb.StreamResponse_ChatMessage{
-				ChatMessage: &pb.StreamResponse_Message{
-					Username: client.username,
-					Message:  message,
-				},
-			},
-		}
+		eventTimestamp := s.clock.Sync(clocks.From(in.Timestamp))
 
-		go s.Broadcast(response)
+		switch action := in.Action.(type) {
+		case *pb.StreamRequest_EditRequest:
+			s.handleEdit(client, eventTimestamp, action.EditRequest)
+		case *pb.StreamRequest_DeleteRequest:
+			s.handleDelete(client, eventTimestamp, action.DeleteRequest)
+		case *pb.StreamRequest_ReactRequest:
+			s.handleReact(client, eventTimestamp, action.ReactRequest)
+		case *pb.StreamRequest_NickRequest:
+			s.handleNick(client, eventTimestamp, action.NickRequest)
+		case *pb.StreamRequest_PrivateRequest:
+			s.handlePrivate(client, eventTimestamp, action.PrivateRequest)
+		case *pb.StreamRequest_JoinRequest:
+			s.handleJoin(client, eventTimestamp, action.JoinRequest)
+		case *pb.StreamRequest_WhoRequest:
+			s.handleWho(client, action.WhoRequest)
+		case *pb.StreamRequest_MuteRequest:
+			s.handleMute(client, eventTimestamp, action.MuteRequest)
+		case *pb.StreamRequest_UnmuteRequest:
+			s.handleUnmute(client, eventTimestamp, action.UnmuteRequest)
+		case *pb.StreamRequest_KickRequest:
+			s.handleKickRequest(client, eventTimestamp, action.KickRequest)
+		case *pb.StreamRequest_BanRequest:
+			s.handleBan(client, eventTimestamp, action.BanRequest)
+		case *pb.StreamRequest_UnbanRequest:
+			s.handleUnban(client, eventTimestamp, action.UnbanRequest)
+		case *pb.StreamRequest_RoleRequest:
+			s.handleSetRole(client, eventTimestamp, action.RoleRequest)
+		case *pb.StreamRequest_PasswordRequest:
+			s.handleSetPassword(client, eventTimestamp, action.PasswordRequest)
+		default:
+			s.handleChatMessage(client, eventTimestamp, in.GetMessage(), in.GetParentId(), in.GetEmote())
+		}
 	}
 }
 
@@ -195,6 +349,11 @@ func (s *Server) DisconnectClient(c *Client) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// The client may already have been removed by a kick
+	if s.clients[c.token] != c {
+		return
+	}
+
 	delete(s.usernames, c.username)
 	delete(s.clients, c.token)
 
@@ -215,21 +374,55 @@ func (s *Server) DisconnectClient(c *Client) {
 }
 
 func (s *Server) Broadcast(response *pb.StreamResponse) {
-	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"broadcast\", message=\"%v\"", response.Timestamp, response)
+	s.BroadcastRoom("", response)
+}
+
+// BroadcastRoom sends the response to every client in the room, or to every client if the room is empty
+func (s *Server) BroadcastRoom(room string, response *pb.StreamResponse) {
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"broadcast\", room=\"%v\", message=\"%v\"", response.Timestamp, room, response)
+	if event, ok := transcriptEvent(room, response); ok {
+		s.transcript.Add(event)
+		if s.eventLog != nil {
+			if err := s.eventLog.Append(event); err != nil {
+				utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"event log error\", error=\"%v\"", response.Timestamp, err)
+			}
+		}
+	}
+	s.search.Update(response)
 	s.mu.Lock()
 	for _, client := range s.clients {
+		if room != "" && client.room != room {
+			continue
+		}
 		if client.send != nil {
 			select {
 			case client.send <- response:
 			default: // If a client is slow (their send channel is full) we simply drop the messages
+				s.stats.dropped.Add(1)
 				continue
 			}
 		}
 	}
 	s.mu.Unlock()
+	s.stats.broadcast.Add(1)
 	s.clock.Tick()
 }
 
+// SendTo sends the response to a single client, dropping it if the client is slow
+func (s *Server) SendTo(client *Client, response *pb.StreamResponse) {
+	if client.send == nil {
+		return
+	}
+
+	select {
+	case client.send <- response:
+	default:
+		s.stats.dropped.Add(1)
+	}
+}
+
+const adminTokenFile = "admin_token"
+
 func main() {
 	f, err := os.OpenFile("serverlogfile", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
@@ -239,22 +432,142 @@ func main() {
 
 	log.SetOutput(f)
 
-	ip := "localhost:5001"
-	lis, err := net.Listen("tcp", ip)
+	address := flag.String("address", "localhost:5001", "the address to listen on")
+	adminToken := flag.String("admin-token", os.Getenv("CHITCHAT_ADMIN_TOKEN"), "the token required by the admin service, generated and written to "+adminTokenFile+" if empty")
+	messageLimit := flag.Uint("message-limit", 128, "the maximum length of a chat message in characters")
+	minMessageLength := flag.Int("min-message-length", 1, "the minimum length of a chat message in characters")
+	maxMessageLines := flag.Int("max-message-lines", 10, "the maximum number of lines of a chat message, 0 for no limit")
+	moderationFile := flag.String("moderation-file", "moderation.json", "the file accounts, mutes and bans are stored in")
+	filterFile := flag.String("filter-file", "", "a JSON file with the content filters to apply to chat messages")
+	metricsAddress := flag.String("metrics-address", "localhost:9101", "the address to serve /metrics on, empty to disable")
+	drainTimeout := flag.Duration("drain-timeout", 10*time.Second, "how long connected clients may keep chatting after SIGINT or SIGTERM")
+	transcriptSize := flag.Int("transcript-size", 10000, "the number of events kept for transcript exports")
+	searchSize := flag.Int("search-size", 10000, "the number of messages kept in the search index")
+	eventLogFile := flag.String("event-log", "events.jsonl", "the file broadcast events are kept in, empty to keep them in memory only")
+	retentionFile := flag.String("retention-file", "", "a JSON file with retention policies per room")
+	retainAge := flag.Duration("retain-age", 30*24*time.Hour, "remove events older than this from the event log, 0 to keep them")
+	retainCount := flag.Int("retain-count", 0, "the number of events kept per room in the event log, 0 for no limit")
+	retainBytes := flag.Int64("retain-bytes", 0, "the size in bytes of the events kept per room in the event log, 0 for no limit")
+	compactInterval := flag.Duration("compact-interval", 10*time.Minute, "how often the event log is compacted")
+	owner := flag.String("owner", "", "make this username the owner of the server")
+	ownerPassword := flag.String("owner-password", os.Getenv("CHITCHAT_OWNER_PASSWORD"), "the password of the owner, required unless the owner already has one")
+	rate := flag.Float64("rate", 2, "the number of requests per second a client may send, 0 to disable rate limiting")
+	burst := flag.Int("burst", 10, "the number of requests a client may send at once before being throttled")
+	maxStrikes := flag.Int("max-strikes", 20, "disconnect clients after this many throttled requests within "+strikeWindow.String()+", 0 to never disconnect")
+	flag.Parse()
+
+	if *transcriptSize < 1 {
+		log.Fatalf("-transcript-size must be at least 1")
+	}
+	if *searchSize < 1 {
+		log.Fatalf("-search-size must be at least 1")
+	}
+
+	store, err := OpenModerationStore(*moderationFile)
+	if err != nil {
+		log.Fatalf("failed to open moderation store: %v", err)
+	}
+	if *owner != "" {
+		if *ownerPassword != "" {
+			if err := store.SetPassword(*owner, *ownerPassword); err != nil {
+				log.Fatalf("failed to set the owner password: %v", err)
+			}
+		} else if !store.HasPassword(*owner) {
+			log.Fatalf("the owner needs a password, see -owner-password")
+		}
+		if err := store.SetRole(*owner, Owner); err != nil {
+			log.Fatalf("failed to set owner: %v", err)
+		}
+	}
+
+	filters := NewFilterChain()
+	if *filterFile != "" {
+		filters, err = LoadFilterChain(*filterFile)
+		if err != nil {
+			log.Fatalf("failed to load filters: %v", err)
+		}
+		utils.LogAndPrint("loaded %v content filters from %v", filters.Len(), *filterFile)
+	}
+
+	if *adminToken == "" {
+		*adminToken = GenerateSecureToken()
+		if err := os.WriteFile(adminTokenFile, []byte(*adminToken), 0600); err != nil {
+			log.Fatalf("failed to write admin token: %v", err)
+		}
+		utils.LogAndPrint("admin token written to %v", adminTokenFile)
+	}
+
+	lis, err := net.Listen("tcp", *address)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 		return
 	}
-	var opts []grpc.ServerOption
-	grpcServer := grpc.NewServer(opts...)
-
 	chitchat := &Server{
-		usernames: make(map[string]bool),
-		clients:   make(map[string]*Client),
-		clock:     *clocks.NewLamport(),
+		usernames:  make(map[string]bool),
+		clients:    make(map[string]*Client),
+		history:    NewHistory(1024),
+		transcript: NewTranscript(*transcriptSize),
+		search:     NewSearchIndex(*searchSize),
+		store:      store,
+		clock:      *clocks.NewLamport(),
+		stats:      Stats{started: time.Now()},
+		rateLimit:  RateLimit{Rate: *rate, Burst: *burst, MaxStrikes: *maxStrikes},
+
+		minMessageLength: *minMessageLength,
+		maxMessageLines:  *maxMessageLines,
+		validator:        DefaultValidator(),
+		filters:          filters,
 	}
+	chitchat.messageLimit.Store(uint32(*messageLimit))
+
+	if *eventLogFile != "" {
+		retention := Retention{Default: RetentionPolicy{MaxAge: Duration(*retainAge), MaxCount: *retainCount, MaxBytes: *retainBytes}}
+		if *retentionFile != "" {
+			retention, err = LoadRetention(*retentionFile, retention.Default)
+			if err != nil {
+				log.Fatalf("failed to load retention policies: %v", err)
+			}
+		}
+
+		eventLog, events, err := OpenEventLog(*eventLogFile)
+		if err != nil {
+			log.Fatalf("failed to open event log: %v", err)
+		}
+		defer eventLog.Close()
+
+		events = retention.Apply(events, time.Now())
+		chitchat.transcript.Load(events)
+		chitchat.search.Load(events)
+		// Continue the Lamport clock and the message IDs from where the previous run left off
+		var lamport uint64
+		for _, e := range events {
+			lamport = max(lamport, e.Lamport)
+			chitchat.history.ResumeAfter(e.ID)
+		}
+		chitchat.clock.Sync(clocks.From(lamport))
+		chitchat.eventLog = eventLog
+		utils.LogAndPrint("loaded %v events from %v", len(events), *eventLogFile)
+
+		go chitchat.compactEvery(*compactInterval, retention)
+	}
+
+	interceptors := NewInterceptors(chitchat, *adminToken)
+	grpcServer := grpc.NewServer(interceptors.ServerOptions()...)
 
 	pb.RegisterChitChatServiceServer(grpcServer, chitchat)
+	pb.RegisterAdminServiceServer(grpcServer, NewAdminServer(chitchat))
+	chitchat.registerHealth(grpcServer)
+	go chitchat.shutdownOnSignal(grpcServer, *drainTimeout)
+
+	if *metricsAddress != "" {
+		metrics := NewMetricsHandler(chitchat, interceptors.metrics)
+		go func() {
+			utils.LogAndPrint("metrics served at http://%v/metrics", *metricsAddress)
+			if err := metrics.ServeMetrics(*metricsAddress); err != nil {
+				utils.LogAndPrint("metrics server stopped: %v", err)
+			}
+		}()
+	}
 	utils.LogAndPrint("server listening at %v", lis.Addr())
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
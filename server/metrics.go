@@ -0,0 +1,155 @@
+package main
+
+// An HTTP endpoint exposing the metrics of the server in the Prometheus text format
+// Everything is computed from in-process counters, so no outside services are needed
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateWindow is the number of seconds the per second rates are averaged over
+const rateWindow = 10
+
+// RateSampler samples a counter once per second, so that its rate can be reported
+type RateSampler struct {
+	mu      sync.Mutex
+	counter func() uint64
+	samples []uint64
+}
+
+func NewRateSampler(counter func() uint64) *RateSampler {
+	return &RateSampler{counter: counter}
+}
+
+// Run samples the counter every second until the done channel is closed
+func (r *RateSampler) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.samples = append(r.samples, r.counter())
+			if len(r.samples) > rateWindow+1 {
+				r.samples = r.samples[1:]
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// PerSecond returns the average rate over the sampled window
+func (r *RateSampler) PerSecond() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	return float64(last-first) / float64(len(r.samples)-1)
+}
+
+// MetricsHandler serves /metrics
+type MetricsHandler struct {
+	chat *Server
+	rpcs *RPCMetrics
+
+	receivedRate  *RateSampler
+	broadcastRate *RateSampler
+}
+
+func NewMetricsHandler(chat *Server, rpcs *RPCMetrics) *MetricsHandler {
+	return &MetricsHandler{
+		chat:          chat,
+		rpcs:          rpcs,
+		receivedRate:  NewRateSampler(chat.stats.received.Load),
+		broadcastRate: NewRateSampler(chat.stats.broadcast.Load),
+	}
+}
+
+// escapeLabel escapes a label value as required by the text format
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func writeMetric(w io.Writer, name string, kind string, help string, value any) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+}
+
+func (m *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := m.chat
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	type queue struct {
+		username string
+		depth    int
+		capacity int
+	}
+
+	s.mu.Lock()
+	clients := len(s.clients)
+	queues := []queue{}
+	for _, c := range s.clients {
+		if c.send != nil {
+			queues = append(queues, queue{username: c.username, depth: len(c.send), capacity: cap(c.send)})
+		}
+	}
+	s.mu.Unlock()
+
+	writeMetric(w, "chitchat_uptime_seconds", "gauge", "Seconds since the server started.", int64(s.stats.Uptime().Seconds()))
+	writeMetric(w, "chitchat_connected_clients", "gauge", "Number of connected clients.", clients)
+	writeMetric(w, "chitchat_messages_received_total", "counter", "Requests received from clients.", s.stats.received.Load())
+	writeMetric(w, "chitchat_messages_broadcast_total", "counter", "Events queued for delivery to clients.", s.stats.broadcast.Load())
+	writeMetric(w, "chitchat_messages_dropped_total", "counter", "Events dropped because the send queue of a client was full.", s.stats.dropped.Load())
+	writeMetric(w, "chitchat_messages_throttled_total", "counter", "Requests dropped by the rate limiter.", s.stats.throttled.Load())
+	writeMetric(w, "chitchat_messages_received_per_second", "gauge", fmt.Sprintf("Requests received per second, averaged over %d seconds.", rateWindow), m.receivedRate.PerSecond())
+	writeMetric(w, "chitchat_messages_broadcast_per_second", "gauge", fmt.Sprintf("Events queued per second, averaged over %d seconds.", rateWindow), m.broadcastRate.PerSecond())
+	writeMetric(w, "chitchat_lamport_clock", "gauge", "Current value of the Lamport clock of the server.", s.clock.Now())
+	writeMetric(w, "chitchat_message_limit", "gauge", "Maximum length of a chat message in characters.", s.messageLimit.Load())
+
+	fmt.Fprintf(w, "# HELP chitchat_send_queue_depth Events waiting to be sent to a client.\n# TYPE chitchat_send_queue_depth gauge\n")
+	for _, q := range queues {
+		fmt.Fprintf(w, "chitchat_send_queue_depth{username=\"%s\"} %d\n", escapeLabel(q.username), q.depth)
+	}
+	fmt.Fprintf(w, "# HELP chitchat_send_queue_capacity Size of the send queue of a client.\n# TYPE chitchat_send_queue_capacity gauge\n")
+	for _, q := range queues {
+		fmt.Fprintf(w, "chitchat_send_queue_capacity{username=\"%s\"} %d\n", escapeLabel(q.username), q.capacity)
+	}
+
+	methods, stats := m.rpcs.Snapshot()
+	fmt.Fprintf(w, "# HELP chitchat_rpc_errors_total RPCs which returned an error.\n# TYPE chitchat_rpc_errors_total counter\n")
+	for i, method := range methods {
+		fmt.Fprintf(w, "chitchat_rpc_errors_total{method=\"%s\"} %d\n", escapeLabel(method), stats[i].Errors)
+	}
+	fmt.Fprintf(w, "# HELP chitchat_rpc_duration_seconds Latency of RPCs, for streams how long they were open.\n# TYPE chitchat_rpc_duration_seconds histogram\n")
+	for i, method := range methods {
+		label := escapeLabel(method)
+		for j, bound := range LatencyBuckets {
+			fmt.Fprintf(w, "chitchat_rpc_duration_seconds_bucket{method=\"%s\",le=\"%v\"} %d\n", label, bound.Seconds(), stats[i].Buckets[j])
+		}
+		fmt.Fprintf(w, "chitchat_rpc_duration_seconds_bucket{method=\"%s\",le=\"+Inf\"} %d\n", label, stats[i].Calls)
+		fmt.Fprintf(w, "chitchat_rpc_duration_seconds_sum{method=\"%s\"} %v\n", label, stats[i].Latency.Seconds())
+		fmt.Fprintf(w, "chitchat_rpc_duration_seconds_count{method=\"%s\"} %d\n", label, stats[i].Calls)
+	}
+}
+
+// ServeMetrics starts sampling the rates and serves /metrics on the address until the server exits
+func (m *MetricsHandler) ServeMetrics(address string) error {
+	done := make(chan struct{})
+	defer close(done)
+	go m.receivedRate.Run(done)
+	go m.broadcastRate.Run(done)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	return http.ListenAndServe(address, mux)
+}
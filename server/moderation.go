@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Kick removes the client from the server, telling it why if it has opened its stream
+func (s *Server) Kick(client *Client, reason string) {
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_KickEvent{
+			KickEvent: &pb.StreamResponse_Kick{
+				Reason: reason,
+			},
+		},
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"kick\", username=\"%v\", reason=\"%v\"", response.Timestamp, client.username, reason)
+
+	s.mu.Lock()
+	streaming := client.stream != nil
+	s.mu.Unlock()
+
+	if !streaming {
+		s.DisconnectClient(client)
+		return
+	}
+
+	// The Stream handler of the client disconnects it once the event has been sent
+	select {
+	case client.kick <- response:
+	default: // The client is already being kicked
+	}
+}
+
+// BanUsername prevents the username from logging in and kicks the user if they are online
+// Returns the usernames of the kicked sessions
+func (s *Server) BanUsername(username string, reason string) ([]string, error) {
+	if err := s.store.BanUsername(username, reason); err != nil {
+		return nil, err
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"ban\", username=\"%v\", reason=\"%v\"", s.clock.Now(), username, reason)
+
+	s.mu.Lock()
+	client := s.clientByName(username)
+	s.mu.Unlock()
+
+	if client == nil {
+		return nil, nil
+	}
+
+	s.Kick(client, "banned: "+reason)
+	return []string{client.username}, nil
+}
+
+// BanIP prevents connections from the IP address and kicks every session using it
+// Returns the usernames of the kicked sessions
+func (s *Server) BanIP(ip string, reason string) ([]string, error) {
+	if err := s.store.BanIP(ip, reason); err != nil {
+		return nil, err
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"ban\", ip=\"%v\", reason=\"%v\"", s.clock.Now(), ip, reason)
+
+	s.mu.Lock()
+	affected := []*Client{}
+	for _, c := range s.clients {
+		if c.ip == ip {
+			affected = append(affected, c)
+		}
+	}
+	s.mu.Unlock()
+
+	kicked := []string{}
+	for _, c := range affected {
+		s.Kick(c, "banned: "+reason)
+		kicked = append(kicked, c.username)
+	}
+	return kicked, nil
+}
+
+// Announce sends a system message to every client
+func (s *Server) Announce(message string) {
+	s.clock.Tick()
+	response := &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_SystemEvent{
+			SystemEvent: &pb.StreamResponse_System{
+				Message: message,
+			},
+		},
+	}
+
+	go s.Broadcast(response)
+}
+
+// speaks reports whether the request would make the client heard by others, which muted users may not do
+func speaks(in *pb.StreamRequest) bool {
+	switch in.Action.(type) {
+	case *pb.StreamRequest_WhoRequest, *pb.StreamRequest_JoinRequest, *pb.StreamRequest_DeleteRequest:
+		return false
+	case *pb.StreamRequest_MuteRequest, *pb.StreamRequest_UnmuteRequest, *pb.StreamRequest_KickRequest:
+		return false
+	case *pb.StreamRequest_BanRequest, *pb.StreamRequest_UnbanRequest, *pb.StreamRequest_RoleRequest, *pb.StreamRequest_PasswordRequest:
+		return false
+	}
+	return true
+}
+
+// mayModerate checks that the client is allowed to moderate the target
+// Moderators can act on members, but only the owner can act on moderators
+func (s *Server) mayModerate(client *Client, target string) bool {
+	actor := s.store.Role(client.username)
+	return actor.rank() >= Moderator.rank() && actor.rank() > s.store.Role(target).rank()
+}
+
+// readableRoom returns the room the client may read when asking for the room, e.g. to search or export it
+// Members can only read the room they are in, which is also what an empty room means for them. Moderators can read
+// any room, and every room at once by leaving the room empty
+func (s *Server) readableRoom(client *Client, room string) (string, error) {
+	if s.store.Role(client.username).rank() >= Moderator.rank() {
+		return room, nil
+	}
+
+	s.mu.Lock()
+	current := client.room
+	s.mu.Unlock()
+
+	if room != "" && room != current {
+		return "", status.Errorf(codes.PermissionDenied, "join #%s to read it", room)
+	}
+	return current, nil
+}
+
+// authorizeModeration rejects the request if the client may not moderate the target
+func (s *Server) authorizeModeration(client *Client, eventTimestamp uint64, action string, target string) bool {
+	if s.mayModerate(client, target) {
+		return true
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"refused moderation\", username=\"%v\", action=\"%v\", target=\"%v\"", eventTimestamp, client.username, action, target)
+	s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, fmt.Sprintf("you are not allowed to %s %s", action, target))
+	return false
+}
+
+// storeFailed reports a failure to persist a moderation change
+func (s *Server) storeFailed(client *Client, err error) {
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"store error\", error=\"%v\"", s.clock.Now(), err)
+	s.Reject(client, pb.StreamResponse_Error_UNKNOWN, "the change could not be saved")
+}
+
+func (s *Server) handleMute(client *Client, eventTimestamp uint64, mute *pb.StreamRequest_Mute) {
+	if !s.authorizeModeration(client, eventTimestamp, "mute", mute.GetUsername()) {
+		return
+	}
+
+	duration := time.Duration(mute.GetDurationSeconds()) * time.Second
+	if duration <= 0 {
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, "the mute duration must be positive")
+		return
+	}
+
+	reason, ok := s.validateReason(client, eventTimestamp, mute.GetReason())
+	if !ok {
+		return
+	}
+
+	if err := s.store.Mute(mute.GetUsername(), duration, reason); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"mute\", username=\"%v\", target=\"%v\", duration=\"%v\", reason=\"%v\"", eventTimestamp, client.username, mute.GetUsername(), duration, reason)
+	s.Announce(fmt.Sprintf("%s was muted by %s for %v%s", mute.GetUsername(), client.username, duration, formatReason(reason)))
+}
+
+func (s *Server) handleUnmute(client *Client, eventTimestamp uint64, unmute *pb.StreamRequest_Unmute) {
+	if !s.authorizeModeration(client, eventTimestamp, "unmute", unmute.GetUsername()) {
+		return
+	}
+
+	if err := s.store.Unmute(unmute.GetUsername()); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"unmute\", username=\"%v\", target=\"%v\"", eventTimestamp, client.username, unmute.GetUsername())
+	s.Announce(fmt.Sprintf("%s was unmuted by %s", unmute.GetUsername(), client.username))
+}
+
+func (s *Server) handleKickRequest(client *Client, eventTimestamp uint64, kick *pb.StreamRequest_Kick) {
+	if !s.authorizeModeration(client, eventTimestamp, "kick", kick.GetUsername()) {
+		return
+	}
+
+	kickReason, ok := s.validateReason(client, eventTimestamp, kick.GetReason())
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	target := s.clientByName(kick.GetUsername())
+	s.mu.Unlock()
+
+	if target == nil {
+		s.Reject(client, pb.StreamResponse_Error_NOT_FOUND, fmt.Sprintf("%s is not online", kick.GetUsername()))
+		return
+	}
+
+	reason := fmt.Sprintf("kicked by %s%s", client.username, formatReason(kickReason))
+	s.Kick(target, reason)
+	s.Announce(fmt.Sprintf("%s was %s", target.username, reason))
+}
+
+func (s *Server) handleBan(client *Client, eventTimestamp uint64, ban *pb.StreamRequest_Ban) {
+	if !s.authorizeModeration(client, eventTimestamp, "ban", ban.GetUsername()) {
+		return
+	}
+
+	banReason, ok := s.validateReason(client, eventTimestamp, ban.GetReason())
+	if !ok {
+		return
+	}
+
+	reason := fmt.Sprintf("by %s%s", client.username, formatReason(banReason))
+	if _, err := s.BanUsername(ban.GetUsername(), reason); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	s.Announce(fmt.Sprintf("%s was banned %s", ban.GetUsername(), reason))
+}
+
+func (s *Server) handleUnban(client *Client, eventTimestamp uint64, unban *pb.StreamRequest_Unban) {
+	if !s.authorizeModeration(client, eventTimestamp, "unban", unban.GetUsername()) {
+		return
+	}
+
+	if err := s.store.UnbanUsername(unban.GetUsername()); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"unban\", username=\"%v\", target=\"%v\"", eventTimestamp, client.username, unban.GetUsername())
+	s.Announce(fmt.Sprintf("%s was unbanned by %s", unban.GetUsername(), client.username))
+}
+
+func (s *Server) handleSetRole(client *Client, eventTimestamp uint64, req *pb.StreamRequest_SetRole) {
+	if s.store.Role(client.username) != Owner {
+		s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, "only the owner can change roles")
+		return
+	}
+
+	role := RoleFromProto(req.GetRole())
+	if role != Member && !s.store.HasPassword(req.GetUsername()) {
+		s.Reject(client, pb.StreamResponse_Error_PERMISSION_DENIED, fmt.Sprintf("%s needs to set a password with /password first", req.GetUsername()))
+		return
+	}
+
+	if err := s.store.SetRole(req.GetUsername(), role); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"role change\", username=\"%v\", target=\"%v\", role=\"%v\"", eventTimestamp, client.username, req.GetUsername(), role)
+	s.Announce(fmt.Sprintf("%s is now a %s", req.GetUsername(), role))
+}
+
+// minPasswordLength is the least number of characters a password must have
+const minPasswordLength = 8
+
+func (s *Server) handleSetPassword(client *Client, eventTimestamp uint64, req *pb.StreamRequest_SetPassword) {
+	if utf8.RuneCountInString(req.GetPassword()) < minPasswordLength {
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, fmt.Sprintf("passwords must have at least %d characters", minPasswordLength))
+		return
+	}
+
+	if err := s.store.SetPassword(client.username, req.GetPassword()); err != nil {
+		s.storeFailed(client, err)
+		return
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"password change\", username=\"%v\"", eventTimestamp, client.username)
+
+	s.clock.Tick()
+	s.SendTo(client, &pb.StreamResponse{
+		Timestamp: s.clock.Now(),
+		Event: &pb.StreamResponse_SystemEvent{
+			SystemEvent: &pb.StreamResponse_System{
+				Message: fmt.Sprintf("the password of %s was set, it is needed to connect from now on", client.username),
+			},
+		},
+	})
+}
+
+// formatReason formats an optional reason to be appended to a message, it must have been through validateReason
+func formatReason(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return " (" + reason + ")"
+}
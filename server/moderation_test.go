@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestValidateReason(t *testing.T) {
+	s := &Server{validator: DefaultValidator()}
+	s.messageLimit.Store(20)
+	client := &Client{username: "alice"}
+
+	tests := []struct {
+		reason string
+		want   string
+		ok     bool
+	}{
+		{"", "", true},
+		{"   ", "", true},
+		{"spam", "spam", true},
+		{"  spam  ", "spam", true},
+		{"\x1b[2Jspam\x1b]0;title\x07", "spam", true},
+		{"sp\x00am\u0085", "spam", true},
+		{"two\nlines", "", false},
+		{"far too long for the limit", "", false},
+		{"\xff", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := s.validateReason(client, 0, test.reason)
+		if got != test.want || ok != test.ok {
+			t.Errorf("validateReason(%q) = %q, %v, want %q, %v", test.reason, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+)
+
+// strikeWindow is how long a throttled request counts against a client
+const strikeWindow = 30 * time.Second
+
+// RateLimit configures the token bucket every session gets
+type RateLimit struct {
+	// Requests per second which are refilled into the bucket, 0 disables rate limiting
+	Rate float64
+	// The number of requests which may be sent at once before throttling starts
+	Burst int
+	// The number of throttled requests within strikeWindow before the client is disconnected, 0 disables it
+	MaxStrikes int
+}
+
+// TokenBucket limits the rate of requests of a single session
+type TokenBucket struct {
+	mu     sync.Mutex
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+
+	// The times of the throttled requests within the last strikeWindow, oldest first
+	strikes []time.Time
+}
+
+func NewTokenBucket(limit RateLimit) *TokenBucket {
+	return &TokenBucket{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket
+// If the bucket is empty the request is throttled, and the number of strikes within strikeWindow is returned
+func (b *TokenBucket) Allow() (bool, int) {
+	return b.allow(time.Now())
+}
+
+func (b *TokenBucket) allow(now time.Time) (bool, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate, float64(b.limit.Burst))
+	b.last = now
+
+	// Every strike counts for strikeWindow after it happened
+	expired := 0
+	for expired < len(b.strikes) && now.Sub(b.strikes[expired]) > strikeWindow {
+		expired++
+	}
+	b.strikes = b.strikes[expired:]
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, len(b.strikes)
+	}
+
+	b.strikes = append(b.strikes, now)
+	// Only whether the limit is reached matters, so the strikes beyond it are not kept
+	if b.limit.MaxStrikes > 0 && len(b.strikes) > b.limit.MaxStrikes {
+		b.strikes = b.strikes[len(b.strikes)-b.limit.MaxStrikes:]
+	}
+
+	return false, len(b.strikes)
+}
+
+// RetryAfter returns how long it takes until the next request is allowed
+func (b *TokenBucket) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens >= 1 || b.limit.Rate <= 0 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// Exceeded reports whether the client has been throttled too often and should be disconnected
+func (limit RateLimit) Exceeded(strikes int) bool {
+	return limit.MaxStrikes > 0 && strikes >= limit.MaxStrikes
+}
+
+// throttle checks the rate limit of the client before a request is handled
+// Throttled clients are told when to try again, and repeat offenders are disconnected
+func (s *Server) throttle(client *Client) bool {
+	if s.rateLimit.Rate <= 0 {
+		return true
+	}
+
+	allowed, strikes := client.limiter.Allow()
+	if allowed {
+		return true
+	}
+
+	s.stats.throttled.Add(1)
+
+	if s.rateLimit.Exceeded(strikes) {
+		utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"flood disconnect\", username=\"%v\", ip=\"%v\", strikes=\"%v\"", s.clock.Now(), client.username, client.ip, strikes)
+		s.Kick(client, "disconnected for flooding")
+		return false
+	}
+
+	retry := client.limiter.RetryAfter().Round(time.Millisecond)
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"throttled\", username=\"%v\", ip=\"%v\", strikes=\"%v\"", s.clock.Now(), client.username, client.ip, strikes)
+	s.Reject(client, pb.StreamResponse_Error_THROTTLED, fmt.Sprintf("you are sending too fast, try again in %v", retry))
+	return false
+}
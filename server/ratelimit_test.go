@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketStrikes(t *testing.T) {
+	limit := RateLimit{Rate: 0.001, Burst: 1, MaxStrikes: 3}
+	start := time.Now()
+
+	tests := []struct {
+		name string
+		// When the requests are sent, relative to the start
+		requests []time.Duration
+		// The strikes counted after every request
+		want []int
+	}{
+		{"burst", []time.Duration{0}, []int{0}},
+		{"strikes in a row", []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, []int{0, 1, 2, 3}},
+		// A strike just under the window after the previous one only counts that one
+		{"spaced just under the window", []time.Duration{0, 0, 29 * time.Second, 58 * time.Second, 87 * time.Second, 116 * time.Second}, []int{0, 1, 2, 2, 2, 2}},
+		{"older strikes expire", []time.Duration{0, 0, 10 * time.Second, 20 * time.Second, 41 * time.Second}, []int{0, 1, 2, 3, 2}},
+	}
+
+	for _, test := range tests {
+		bucket := NewTokenBucket(limit)
+		bucket.last = start
+		for i, at := range test.requests {
+			_, strikes := bucket.allow(start.Add(at))
+			if strikes != test.want[i] {
+				t.Errorf("%s: request %d counted %d strikes, want %d", test.name, i, strikes, test.want[i])
+			}
+		}
+	}
+
+	// Requests every 29 seconds are throttled forever without reaching the limit
+	bucket := NewTokenBucket(limit)
+	bucket.last = start
+	for i := 0; i < 100; i++ {
+		if _, strikes := bucket.allow(start.Add(time.Duration(i) * 29 * time.Second)); limit.Exceeded(strikes) {
+			t.Fatalf("disconnected after %d requests spaced 29s apart", i+1)
+		}
+	}
+}
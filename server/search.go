@@ -0,0 +1,317 @@
+package main
+
+// Full-text search over the chat messages which were broadcast
+// The index is kept in memory and updated as messages are sent, edited and deleted. On startup it is filled from the
+// event log, and messages are removed from it once the retention policies remove them from the log
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	pb "ChitChat/grpc"
+	clocks "ChitChat/logical_clocks"
+	"ChitChat/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchContext   = 5
+)
+
+type indexedMessage struct {
+	message *pb.StreamResponse_Message
+	lamport uint64
+	time    time.Time
+	terms   []string
+}
+
+// SearchIndex is an inverted index from terms to the IDs of the messages containing them
+type SearchIndex struct {
+	mu       sync.Mutex
+	capacity int
+
+	messages map[uint64]*indexedMessage
+	postings map[string]map[uint64]struct{}
+	// The IDs of the messages of every room in the order they were sent, used for context and eviction
+	rooms map[string][]uint64
+	order []uint64
+}
+
+func NewSearchIndex(capacity int) *SearchIndex {
+	return &SearchIndex{
+		capacity: capacity,
+		messages: make(map[uint64]*indexedMessage),
+		postings: make(map[string]map[uint64]struct{}),
+		rooms:    make(map[string][]uint64),
+	}
+}
+
+// Tokenize splits text into lower case terms of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexTerms must be called with idx.mu held
+func (idx *SearchIndex) indexTerms(id uint64, terms []string) {
+	for _, term := range terms {
+		ids, exists := idx.postings[term]
+		if !exists {
+			ids = make(map[uint64]struct{})
+			idx.postings[term] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// unindexTerms must be called with idx.mu held
+func (idx *SearchIndex) unindexTerms(id uint64, terms []string) {
+	for _, term := range terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+}
+
+// remove must be called with idx.mu held
+func (idx *SearchIndex) remove(id uint64) {
+	indexed, exists := idx.messages[id]
+	if !exists {
+		return
+	}
+
+	idx.unindexTerms(id, indexed.terms)
+	delete(idx.messages, id)
+
+	room := idx.rooms[indexed.message.Room]
+	i := sort.Search(len(room), func(i int) bool { return room[i] >= id })
+	if i < len(room) && room[i] == id {
+		idx.rooms[indexed.message.Room] = append(room[:i], room[i+1:]...)
+	}
+
+	// Evicted and expired messages are the oldest, so they are found right at the front
+	switch j := slices.Index(idx.order, id); {
+	case j == 0:
+		idx.order = idx.order[1:]
+	case j > 0:
+		idx.order = slices.Delete(idx.order, j, j+1)
+	}
+}
+
+// add must be called with idx.mu held
+func (idx *SearchIndex) add(msg *pb.StreamResponse_Message, lamport uint64, sent time.Time) {
+	if _, exists := idx.messages[msg.Id]; exists {
+		return
+	}
+
+	indexed := &indexedMessage{message: msg, lamport: lamport, time: sent, terms: Tokenize(msg.Message)}
+	idx.messages[msg.Id] = indexed
+	idx.indexTerms(msg.Id, indexed.terms)
+
+	// IDs are handed out in increasing order, but broadcasts may arrive out of order
+	room := idx.rooms[msg.Room]
+	i := sort.Search(len(room), func(i int) bool { return room[i] >= msg.Id })
+	idx.rooms[msg.Room] = append(room[:i], append([]uint64{msg.Id}, room[i:]...)...)
+
+	idx.order = append(idx.order, msg.Id)
+	for len(idx.order) > idx.capacity {
+		idx.remove(idx.order[0])
+	}
+}
+
+// edit must be called with idx.mu held
+func (idx *SearchIndex) edit(id uint64, text string) {
+	indexed, exists := idx.messages[id]
+	if !exists {
+		return
+	}
+
+	idx.unindexTerms(id, indexed.terms)
+	// The message may still be in use by a search response, so it is copied rather than changed
+	edited := proto.Clone(indexed.message).(*pb.StreamResponse_Message)
+	edited.Message = text
+	indexed.message = edited
+	indexed.terms = Tokenize(edited.Message)
+	idx.indexTerms(id, indexed.terms)
+}
+
+// Update applies a broadcast to the index
+func (idx *SearchIndex) Update(response *pb.StreamResponse) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	switch event := response.Event.(type) {
+	case *pb.StreamResponse_ChatMessage:
+		idx.add(event.ChatMessage, response.Timestamp, time.Now())
+	case *pb.StreamResponse_EditEvent:
+		idx.edit(event.EditEvent.Id, event.EditEvent.Message)
+	case *pb.StreamResponse_DeleteEvent:
+		idx.remove(event.DeleteEvent.Id)
+	}
+}
+
+// Load indexes the messages of the events read from the event log, applying their edits and deletions
+// The events must be ordered oldest first
+func (idx *SearchIndex) Load(events []TranscriptEvent) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, e := range events {
+		switch e.Kind {
+		case "message", "emote":
+			msg := &pb.StreamResponse_Message{
+				Username: e.Username,
+				Message:  e.Message,
+				Id:       e.ID,
+				ParentId: e.ParentID,
+				Emote:    e.Kind == "emote",
+				Room:     e.Room,
+			}
+			idx.add(msg, e.Lamport, e.Time)
+		case "edit":
+			idx.edit(e.ID, e.Message)
+		case "delete":
+			idx.remove(e.ID)
+		}
+	}
+}
+
+// Expire removes the messages of the events which were removed from the event log by its retention policies
+func (idx *SearchIndex) Expire(events []TranscriptEvent) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, e := range events {
+		if e.Kind == "message" || e.Kind == "emote" {
+			idx.remove(e.ID)
+		}
+	}
+}
+
+// context must be called with idx.mu held
+// Returns up to n messages of the same room before and after the message
+func (idx *SearchIndex) context(msg *pb.StreamResponse_Message, n int) ([]*pb.StreamResponse_Message, []*pb.StreamResponse_Message) {
+	room := idx.rooms[msg.Room]
+	i := sort.Search(len(room), func(i int) bool { return room[i] >= msg.Id })
+
+	before := []*pb.StreamResponse_Message{}
+	for j := max(i-n, 0); j < i; j++ {
+		before = append(before, idx.messages[room[j]].message)
+	}
+	after := []*pb.StreamResponse_Message{}
+	for j := i + 1; j < len(room) && j <= i+n; j++ {
+		after = append(after, idx.messages[room[j]].message)
+	}
+	return before, after
+}
+
+// candidates must be called with idx.mu held
+// Returns the IDs of the messages containing every term, or every message if there are no terms
+func (idx *SearchIndex) candidates(terms []string) []uint64 {
+	if len(terms) == 0 {
+		ids := make([]uint64, 0, len(idx.messages))
+		for id := range idx.messages {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// Start with the rarest term, so that the fewest IDs have to be checked
+	smallest := idx.postings[terms[0]]
+	for _, term := range terms[1:] {
+		if len(idx.postings[term]) < len(smallest) {
+			smallest = idx.postings[term]
+		}
+	}
+
+	ids := []uint64{}
+	for id := range smallest {
+		matches := true
+		for _, term := range terms {
+			if _, ok := idx.postings[term][id]; !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Search returns the newest matching messages with their context, and the total number of matches
+func (idx *SearchIndex) Search(req *pb.SearchRequest, terms []string) ([]*pb.SearchHit, int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	room := strings.TrimPrefix(req.Room, "#")
+	matches := []*indexedMessage{}
+	for _, id := range idx.candidates(terms) {
+		indexed := idx.messages[id]
+		switch {
+		case req.Username != "" && indexed.message.Username != req.Username:
+		case room != "" && indexed.message.Room != room:
+		case req.FromTimestamp != 0 && indexed.lamport < req.FromTimestamp:
+		case req.ToTimestamp != 0 && indexed.lamport > req.ToTimestamp:
+		case req.Since != 0 && indexed.time.Unix() < req.Since:
+		case req.Until != 0 && indexed.time.Unix() > req.Until:
+		default:
+			matches = append(matches, indexed)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].message.Id > matches[j].message.Id
+	})
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+	contextSize := min(int(req.Context), maxSearchContext)
+
+	hits := []*pb.SearchHit{}
+	for _, indexed := range matches[:min(limit, len(matches))] {
+		hit := &pb.SearchHit{Message: indexed.message, Lamport: indexed.lamport, Time: indexed.time.Unix()}
+		if contextSize > 0 {
+			hit.Before, hit.After = idx.context(indexed.message, contextSize)
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, len(matches)
+}
+
+func (s *Server) Search(ctx context.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
+	client := clientFromContext(ctx)
+	eventTimestamp := s.clock.Sync(clocks.From(req.Timestamp))
+
+	terms := Tokenize(req.Query)
+	if len(terms) == 0 && req.Username == "" && req.Room == "" {
+		return nil, status.Error(codes.InvalidArgument, "search for at least a keyword, a user or a room")
+	}
+
+	room, err := s.readableRoom(client, strings.TrimPrefix(req.Room, "#"))
+	if err != nil {
+		return nil, err
+	}
+	req.Room = room
+
+	hits, total := s.search.Search(req, terms)
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"search\", username=\"%v\", query=\"%v\", user=\"%v\", room=\"%v\", matches=\"%v\"", eventTimestamp, client.username, req.Query, req.Username, req.Room, total)
+
+	return &pb.SearchResponse{Timestamp: eventTimestamp, Hits: hits, Total: uint32(total), Terms: terms}, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	pb "ChitChat/grpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// searchIDs returns the IDs of the messages matching the query, newest first
+func searchIDs(idx *SearchIndex, query string) []uint64 {
+	hits, _ := idx.Search(&pb.SearchRequest{}, Tokenize(query))
+	ids := []uint64{}
+	for _, hit := range hits {
+		ids = append(ids, hit.Message.Id)
+	}
+	return ids
+}
+
+func TestSearchIndexLoad(t *testing.T) {
+	sent := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []TranscriptEvent{
+		{Lamport: 1, Time: sent, Kind: "login", Username: "alice"},
+		{Lamport: 2, Time: sent, Kind: "message", Room: "general", Username: "alice", Message: "deploy the server", ID: 1},
+		{Lamport: 3, Time: sent, Kind: "emote", Room: "general", Username: "bob", Message: "waves at the server", ID: 2},
+		{Lamport: 4, Time: sent, Kind: "message", Room: "dev", Username: "bob", Message: "secret token", ID: 3},
+		{Lamport: 5, Time: sent, Kind: "message", Room: "dev", Username: "alice", Message: "typo in the sever", ID: 4, ParentID: 3},
+		{Lamport: 6, Time: sent, Kind: "edit", Username: "alice", Message: "fixed the server", ID: 4},
+		{Lamport: 7, Time: sent, Kind: "delete", Username: "bob", ID: 3},
+		// An edit of a message which is no longer in the log
+		{Lamport: 8, Time: sent, Kind: "edit", Username: "carol", Message: "server", ID: 99},
+	}
+
+	idx := NewSearchIndex(100)
+	idx.Load(events)
+
+	tests := []struct {
+		query string
+		want  []uint64
+	}{
+		{"server", []uint64{4, 2, 1}},
+		{"deploy", []uint64{1}},
+		{"sever", []uint64{}},
+		{"fixed", []uint64{4}},
+		{"secret", []uint64{}},
+	}
+	for _, test := range tests {
+		if got := searchIDs(idx, test.query); !slices.Equal(got, test.want) {
+			t.Errorf("search %q = %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	hits, _ := idx.Search(&pb.SearchRequest{}, Tokenize("waves"))
+	if len(hits) != 1 || !hits[0].Message.Emote || hits[0].Lamport != 3 || hits[0].Time != sent.Unix() {
+		t.Errorf("the emote was indexed as %v", hits)
+	}
+	hits, _ = idx.Search(&pb.SearchRequest{}, Tokenize("fixed"))
+	if len(hits) != 1 || hits[0].Message.ParentId != 3 || hits[0].Message.Room != "dev" {
+		t.Errorf("the reply was indexed as %v", hits)
+	}
+}
+
+func TestSearchIndexExpire(t *testing.T) {
+	now := time.Now()
+	events := []TranscriptEvent{}
+	for id := uint64(1); id <= 4; id++ {
+		events = append(events, TranscriptEvent{Lamport: id, Time: now.Add(-time.Duration(5-id) * time.Hour), Kind: "message", Room: "general", Username: "alice", Message: "hello", ID: id})
+	}
+
+	idx := NewSearchIndex(100)
+	idx.Load(events)
+
+	// The retention of the event log decides what is removed from the index
+	retention := Retention{Default: RetentionPolicy{MaxAge: Duration(150 * time.Minute)}}
+	retained, removed := retention.Split(events, now)
+	checkIDs(t, retained, 3, 4)
+	checkIDs(t, removed, 1, 2)
+
+	idx.Expire(removed)
+	if got := searchIDs(idx, "hello"); !slices.Equal(got, []uint64{4, 3}) {
+		t.Errorf("search after expiring = %v, want [4 3]", got)
+	}
+
+	// A message sent after the compaction read the log is left alone
+	idx.Update(&pb.StreamResponse{Timestamp: 5, Event: &pb.StreamResponse_ChatMessage{
+		ChatMessage: &pb.StreamResponse_Message{Username: "bob", Message: "hello", Id: 5, Room: "general"},
+	}})
+	idx.Expire(removed)
+	if got := searchIDs(idx, "hello"); !slices.Equal(got, []uint64{5, 4, 3}) {
+		t.Errorf("search after expiring again = %v, want [5 4 3]", got)
+	}
+}
+
+func TestSearchIndexEvictsAfterDeletes(t *testing.T) {
+	idx := NewSearchIndex(3)
+	send := func(id uint64) {
+		idx.Update(&pb.StreamResponse{Timestamp: id, Event: &pb.StreamResponse_ChatMessage{
+			ChatMessage: &pb.StreamResponse_Message{Username: "alice", Message: "hello", Id: id, Room: "general"},
+		}})
+	}
+
+	send(1)
+	send(2)
+	send(3)
+	idx.Update(&pb.StreamResponse{Event: &pb.StreamResponse_DeleteEvent{DeleteEvent: &pb.StreamResponse_Delete{Id: 2}}})
+
+	// The deleted message no longer takes up room, so nothing is evicted yet
+	send(4)
+	if got := searchIDs(idx, "hello"); !slices.Equal(got, []uint64{4, 3, 1}) {
+		t.Errorf("search after deleting = %v, want [4 3 1]", got)
+	}
+
+	send(5)
+	send(6)
+	if got := searchIDs(idx, "hello"); !slices.Equal(got, []uint64{6, 5, 4}) {
+		t.Errorf("search after evicting = %v, want [6 5 4]", got)
+	}
+	if len(idx.order) != 3 {
+		t.Errorf("the index keeps the order of %d messages, want 3", len(idx.order))
+	}
+}
+
+func TestSearchRooms(t *testing.T) {
+	s := newTestServer(t)
+	s.store.SetRole("mod", Moderator)
+	s.search.Load([]TranscriptEvent{
+		{Lamport: 1, Time: time.Now(), Kind: "message", Room: defaultRoom, Username: "alice", Message: "hello", ID: 1},
+		{Lamport: 2, Time: time.Now(), Kind: "message", Room: "secret", Username: "bob", Message: "hello", ID: 2},
+	})
+
+	alice := recordingClient()
+	mod := &Client{username: "mod", room: defaultRoom}
+
+	tests := []struct {
+		name   string
+		client *Client
+		req    *pb.SearchRequest
+		ids    []uint64
+		code   codes.Code
+	}{
+		{"own room", alice, &pb.SearchRequest{Query: "hello", Room: defaultRoom}, []uint64{1}, codes.OK},
+		// Without a room a member searches the room they are in
+		{"no room", alice, &pb.SearchRequest{Query: "hello"}, []uint64{1}, codes.OK},
+		{"by user", alice, &pb.SearchRequest{Username: "bob"}, []uint64{}, codes.OK},
+		{"another room", alice, &pb.SearchRequest{Query: "hello", Room: "#secret"}, nil, codes.PermissionDenied},
+		{"moderator in another room", mod, &pb.SearchRequest{Query: "hello", Room: "secret"}, []uint64{2}, codes.OK},
+		{"moderator in every room", mod, &pb.SearchRequest{Query: "hello"}, []uint64{2, 1}, codes.OK},
+	}
+
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), clientKey{}, test.client)
+		response, err := s.Search(ctx, test.req)
+		if code := status.Code(err); code != test.code {
+			t.Errorf("%s: got %v, want %v", test.name, code, test.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		ids := []uint64{}
+		for _, hit := range response.Hits {
+			ids = append(ids, hit.Message.Id)
+		}
+		if !slices.Equal(ids, test.ids) {
+			t.Errorf("%s: found %v, want %v", test.name, ids, test.ids)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are simple counters describing the activity of the server since it started
+type Stats struct {
+	started time.Time
+
+	received  atomic.Uint64
+	broadcast atomic.Uint64
+	// Messages which were not delivered because the send channel of a client was full
+	dropped atomic.Uint64
+	// Requests which were rejected by the rate limiter
+	throttled atomic.Uint64
+}
+
+func (st *Stats) Uptime() time.Duration {
+	return time.Since(st.started)
+}
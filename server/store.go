@@ -0,0 +1,313 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "ChitChat/grpc"
+)
+
+type Role string
+
+const (
+	Member    Role = "member"
+	Moderator Role = "moderator"
+	Owner     Role = "owner"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case Owner:
+		return 2
+	case Moderator:
+		return 1
+	}
+	return 0
+}
+
+func RoleFromProto(role pb.Role) Role {
+	switch role {
+	case pb.Role_OWNER:
+		return Owner
+	case pb.Role_MODERATOR:
+		return Moderator
+	}
+	return Member
+}
+
+type Account struct {
+	Role    Role      `json:"role"`
+	Created time.Time `json:"created"`
+	// The salted hash of the password, empty if the account has none
+	PasswordHash []byte `json:"password_hash,omitempty"`
+	PasswordSalt []byte `json:"password_salt,omitempty"`
+}
+
+var (
+	ErrPasswordRequired = errors.New("this username requires a password")
+	ErrWrongPassword    = errors.New("wrong password")
+)
+
+// passwordIterations is the number of PBKDF2 rounds a password is hashed with
+const passwordIterations = 100000
+
+func hashPassword(password string, salt []byte) []byte {
+	hash, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, sha256.Size)
+	if err != nil {
+		panic(err) // Only fails for invalid key lengths
+	}
+	return hash
+}
+
+// protected reports whether logging in with the account requires a password
+// Accounts holding a role always do, so that nobody can take over their powers by picking the username
+func (a *Account) protected() bool {
+	return len(a.PasswordHash) > 0 || a.Role.rank() > Member.rank()
+}
+
+type Mute struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+// storeData is the on-disk representation of the moderation store
+type storeData struct {
+	Accounts    map[string]*Account `json:"accounts"`
+	Mutes       map[string]*Mute    `json:"mutes"`
+	BannedNames map[string]string   `json:"banned_usernames"`
+	BannedIPs   map[string]string   `json:"banned_ips"`
+}
+
+// ModerationStore keeps accounts with their roles together with the mute and ban lists
+// Only usernames with a role or a password have an account, everybody else is a member without one
+// Every change is written to disk right away so that it survives restarts
+type ModerationStore struct {
+	mu   sync.Mutex
+	path string
+	data storeData
+}
+
+func OpenModerationStore(path string) (*ModerationStore, error) {
+	store := &ModerationStore{
+		path: path,
+		data: storeData{
+			Accounts:    make(map[string]*Account),
+			Mutes:       make(map[string]*Mute),
+			BannedNames: make(map[string]string),
+			BannedIPs:   make(map[string]string),
+		},
+	}
+
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contents, &store.data); err != nil {
+		return nil, err
+	}
+
+	// Earlier versions kept an account for every username which ever connected
+	for username, account := range store.data.Accounts {
+		if !account.protected() {
+			delete(store.data.Accounts, username)
+		}
+	}
+
+	return store, nil
+}
+
+// save must be called with st.mu held
+// The file is replaced atomically so that a crash never leaves a half written store behind
+func (st *ModerationStore) save() error {
+	now := time.Now()
+	for username, mute := range st.data.Mutes {
+		if now.After(mute.Until) {
+			delete(st.data.Mutes, username)
+		}
+	}
+
+	contents, err := json.MarshalIndent(&st.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(st.path), filepath.Base(st.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), st.path)
+}
+
+// SetPassword sets the password required to log in with the username
+func (st *ModerationStore) SetPassword(username string, password string) error {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	hash := hashPassword(password, salt)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	account, exists := st.data.Accounts[username]
+	if !exists {
+		account = &Account{Role: Member, Created: time.Now()}
+		st.data.Accounts[username] = account
+	}
+	account.PasswordSalt, account.PasswordHash = salt, hash
+
+	return st.save()
+}
+
+// HasPassword reports whether the username has a password
+func (st *ModerationStore) HasPassword(username string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	account, exists := st.data.Accounts[username]
+	return exists && len(account.PasswordHash) > 0
+}
+
+// Protected reports whether the username requires a password to log in, such names can not be taken with /nick
+func (st *ModerationStore) Protected(username string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	account, exists := st.data.Accounts[username]
+	return exists && account.protected()
+}
+
+// Authenticate checks the password of a user logging in
+// Returns ErrPasswordRequired for accounts holding a role which have no password yet, nobody can log in with those
+// The password is hashed without holding the lock, since that takes a while on purpose
+func (st *ModerationStore) Authenticate(username string, password string) error {
+	st.mu.Lock()
+	account, exists := st.data.Accounts[username]
+	if !exists || !account.protected() {
+		st.mu.Unlock()
+		return nil
+	}
+	hash, salt := account.PasswordHash, account.PasswordSalt
+	st.mu.Unlock()
+
+	if len(hash) == 0 {
+		return ErrPasswordRequired
+	}
+	if subtle.ConstantTimeCompare(hashPassword(password, salt), hash) != 1 {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+func (st *ModerationStore) Role(username string) Role {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	account, exists := st.data.Accounts[username]
+	if !exists {
+		return Member
+	}
+	return account.Role
+}
+
+func (st *ModerationStore) SetRole(username string, role Role) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	account, exists := st.data.Accounts[username]
+	if !exists {
+		account = &Account{Created: time.Now()}
+		st.data.Accounts[username] = account
+	}
+	account.Role = role
+	if !account.protected() {
+		delete(st.data.Accounts, username)
+	}
+
+	return st.save()
+}
+
+func (st *ModerationStore) Mute(username string, duration time.Duration, reason string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.data.Mutes[username] = &Mute{Until: time.Now().Add(duration), Reason: reason}
+	return st.save()
+}
+
+func (st *ModerationStore) Unmute(username string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.data.Mutes, username)
+	return st.save()
+}
+
+// MutedFor returns how much longer the user is muted, or 0 if they are not muted
+func (st *ModerationStore) MutedFor(username string) time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	mute, exists := st.data.Mutes[username]
+	if !exists {
+		return 0
+	}
+	return max(time.Until(mute.Until), 0)
+}
+
+func (st *ModerationStore) BanUsername(username string, reason string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.data.BannedNames[username] = reason
+	return st.save()
+}
+
+func (st *ModerationStore) UnbanUsername(username string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.data.BannedNames, username)
+	return st.save()
+}
+
+func (st *ModerationStore) BanIP(ip string, reason string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.data.BannedIPs[ip] = reason
+	return st.save()
+}
+
+// IsBanned reports whether either the username or the IP address is banned
+func (st *ModerationStore) IsBanned(username string, ip string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, nameBanned := st.data.BannedNames[username]
+	_, ipBanned := st.data.BannedIPs[ip]
+	return nameBanned || ipBanned
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestModerationStoreAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moderation.json")
+	store, err := OpenModerationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetRole("member", Member)
+	store.SetRole("moderator", Moderator)
+	store.SetPassword("owner", "correct horse")
+	store.SetRole("owner", Owner)
+	store.SetPassword("protected", "battery staple")
+
+	tests := []struct {
+		username  string
+		password  string
+		protected bool
+		err       error
+	}{
+		{"unknown", "", false, nil},
+		{"member", "", false, nil},
+		{"member", "anything", false, nil},
+		// A role without a password locks the username rather than leaving it open to anyone
+		{"moderator", "", true, ErrPasswordRequired},
+		{"owner", "", true, ErrWrongPassword},
+		{"owner", "wrong", true, ErrWrongPassword},
+		{"owner", "correct horse", true, nil},
+		{"protected", "", true, ErrWrongPassword},
+		{"protected", "battery staple", true, nil},
+	}
+
+	for _, test := range tests {
+		if err := store.Authenticate(test.username, test.password); !errors.Is(err, test.err) {
+			t.Errorf("Authenticate(%q, %q) = %v, want %v", test.username, test.password, err, test.err)
+		}
+		if protected := store.Protected(test.username); protected != test.protected {
+			t.Errorf("Protected(%q) = %v, want %v", test.username, protected, test.protected)
+		}
+	}
+
+	// The passwords survive reopening the store
+	reopened, err := OpenModerationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Authenticate("owner", "correct horse"); err != nil {
+		t.Errorf("Authenticate after reopening = %v", err)
+	}
+	if err := reopened.Authenticate("owner", "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Authenticate after reopening = %v, want %v", err, ErrWrongPassword)
+	}
+}
+
+func TestModerationStoreKeepsOnlyProtectedAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moderation.json")
+	store, err := OpenModerationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetRole("member", Member)
+	store.SetRole("demoted", Moderator)
+	store.SetRole("demoted", Member)
+	store.SetPassword("protected", "battery staple")
+	store.SetRole("protected", Moderator)
+	store.SetRole("protected", Member)
+	store.BanUsername("banned", "spam")
+
+	// The accounts left by earlier versions for every username which connected are dropped
+	store.mu.Lock()
+	store.data.Accounts["visitor"] = &Account{Role: Member}
+	store.save()
+	store.mu.Unlock()
+
+	reopened, err := OpenModerationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.data.Accounts) != 1 || reopened.data.Accounts["protected"] == nil {
+		t.Errorf("got accounts %v, want only the one with a password", reopened.data.Accounts)
+	}
+	if !reopened.IsBanned("banned", "") {
+		t.Error("the ban was not kept")
+	}
+}
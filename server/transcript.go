@@ -0,0 +1,349 @@
+package main
+
+// The transcript records the broadcast events so that conversations can be exported
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "ChitChat/grpc"
+	clocks "ChitChat/logical_clocks"
+	"ChitChat/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TranscriptEvent is a single recorded event, it is also the format of a line of the JSON lines export
+type TranscriptEvent struct {
+	Lamport  uint64    `json:"lamport"`
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Room     string    `json:"room,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	ID       uint64    `json:"id,omitempty"`
+	ParentID uint64    `json:"parent_id,omitempty"`
+	// The new username of a nick event
+	Target string `json:"target,omitempty"`
+	// Set on exported messages whose text was replaced by a later edit
+	Edited bool `json:"edited,omitempty"`
+}
+
+// TranscriptFilter selects the events to export, zero values match everything
+type TranscriptFilter struct {
+	Since    time.Time
+	Until    time.Time
+	Room     string
+	Username string
+}
+
+func (f TranscriptFilter) matches(e *TranscriptEvent) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	// Events without a room, such as logins, concern every room
+	if f.Room != "" && e.Room != "" && e.Room != f.Room {
+		return false
+	}
+	if f.Username != "" && e.Username != f.Username && e.Target != f.Username {
+		return false
+	}
+	return true
+}
+
+// Transcript keeps the most recent broadcast events
+type Transcript struct {
+	mu       sync.Mutex
+	events   []TranscriptEvent
+	capacity int
+}
+
+func NewTranscript(capacity int) *Transcript {
+	return &Transcript{capacity: capacity}
+}
+
+// transcriptEvent converts a broadcast into a transcript event, events which are not part of the conversation are skipped
+func transcriptEvent(room string, response *pb.StreamResponse) (TranscriptEvent, bool) {
+	e := TranscriptEvent{Lamport: response.Timestamp, Time: time.Now(), Room: room}
+
+	switch event := response.Event.(type) {
+	case *pb.StreamResponse_ChatMessage:
+		msg := event.ChatMessage
+		e.Kind = "message"
+		if msg.Emote {
+			e.Kind = "emote"
+		}
+		e.Room, e.Username, e.Message, e.ID, e.ParentID = msg.Room, msg.Username, msg.Message, msg.Id, msg.ParentId
+	case *pb.StreamResponse_EditEvent:
+		e.Kind, e.Username, e.Message, e.ID = "edit", event.EditEvent.Username, event.EditEvent.Message, event.EditEvent.Id
+	case *pb.StreamResponse_DeleteEvent:
+		e.Kind, e.Username, e.ID = "delete", event.DeleteEvent.Username, event.DeleteEvent.Id
+	case *pb.StreamResponse_LoginEvent:
+		e.Kind, e.Username = "login", event.LoginEvent.Username
+	case *pb.StreamResponse_LogoutEvent:
+		e.Kind, e.Username = "logout", event.LogoutEvent.Username
+	case *pb.StreamResponse_NickEvent:
+		e.Kind, e.Username, e.Target = "nick", event.NickEvent.OldUsername, event.NickEvent.NewUsername
+	case *pb.StreamResponse_JoinEvent:
+		e.Kind, e.Username, e.Room = "join", event.JoinEvent.Username, event.JoinEvent.Room
+	case *pb.StreamResponse_SystemEvent:
+		e.Kind, e.Message = "system", event.SystemEvent.Message
+	default:
+		return e, false
+	}
+
+	return e, true
+}
+
+// Add appends the event to the transcript, dropping the oldest event once the transcript is full
+func (t *Transcript) Add(e TranscriptEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.events) >= t.capacity {
+		t.events = t.events[1:]
+	}
+	t.events = append(t.events, e)
+}
+
+// Load replaces the transcript with the events read from the event log, keeping the newest which fit
+func (t *Transcript) Load(events []TranscriptEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append([]TranscriptEvent(nil), events[max(len(events)-t.capacity, 0):]...)
+}
+
+// Retain removes the events which are past the retention policy of their room
+func (t *Transcript) Retain(retention Retention) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = retention.Apply(t.events, time.Now())
+}
+
+// Events returns the matching events ordered by their Lamport timestamps. Messages carry their latest text,
+// deleted messages are left out and so are the edit and delete events themselves
+func (t *Transcript) Events(filter TranscriptFilter) []TranscriptEvent {
+	t.mu.Lock()
+	all := append([]TranscriptEvent(nil), t.events...)
+	t.mu.Unlock()
+
+	// Broadcasts run concurrently, so they are not necessarily recorded in order
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Lamport < all[j].Lamport
+	})
+
+	// Edits and deletions are applied before filtering, so that they count even when they fall outside the filter
+	events := []TranscriptEvent{}
+	for _, e := range latestVersions(all) {
+		if filter.matches(&e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// latestVersions replaces the text of edited messages with their last edit and drops deleted messages,
+// the events must be ordered by their Lamport timestamps
+func latestVersions(events []TranscriptEvent) []TranscriptEvent {
+	edits := map[uint64]string{}
+	deleted := map[uint64]bool{}
+	for i := range events {
+		switch events[i].Kind {
+		case "edit":
+			edits[events[i].ID] = events[i].Message
+		case "delete":
+			deleted[events[i].ID] = true
+		}
+	}
+
+	latest := make([]TranscriptEvent, 0, len(events))
+	for _, e := range events {
+		switch e.Kind {
+		case "edit", "delete":
+			continue
+		case "message", "emote":
+			if deleted[e.ID] {
+				continue
+			}
+			if text, ok := edits[e.ID]; ok {
+				e.Message, e.Edited = text, true
+			}
+		}
+		latest = append(latest, e)
+	}
+	return latest
+}
+
+// describe returns the text shown for an event in the Markdown and HTML transcripts
+func describe(e *TranscriptEvent) string {
+	switch e.Kind {
+	case "message":
+		text := e.Message
+		if e.ParentID != 0 {
+			text = fmt.Sprintf("(reply to #%d) %s", e.ParentID, text)
+		}
+		if e.Edited {
+			text += " (edited)"
+		}
+		return text
+	case "emote":
+		if e.Edited {
+			return e.Username + " " + e.Message + " (edited)"
+		}
+		return e.Username + " " + e.Message
+	case "login":
+		return "joined the chat"
+	case "logout":
+		return "left the chat"
+	case "nick":
+		return "is now known as " + e.Target
+	case "join":
+		return "joined #" + e.Room
+	}
+	return e.Message
+}
+
+func WriteJSONL(w io.Writer, events []TranscriptEvent) error {
+	encoder := json.NewEncoder(w)
+	for i := range events {
+		if err := encoder.Encode(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "\n", " ",
+)
+
+func WriteMarkdown(w io.Writer, events []TranscriptEvent, title string) error {
+	fmt.Fprintf(w, "# %s\n\n", markdownEscaper.Replace(title))
+	fmt.Fprintf(w, "Exported %s, %d events.\n\n", time.Now().Format(time.DateTime), len(events))
+
+	for i := range events {
+		e := &events[i]
+		fmt.Fprintf(w, "- `%d` `%s`", e.Lamport, e.Time.Format(time.DateTime))
+		if e.Room != "" {
+			fmt.Fprintf(w, " \\#%s", markdownEscaper.Replace(e.Room))
+		}
+		if e.ID != 0 && (e.Kind == "message" || e.Kind == "emote") {
+			fmt.Fprintf(w, " (#%d)", e.ID)
+		}
+
+		text := markdownEscaper.Replace(describe(e))
+		switch e.Kind {
+		case "message":
+			fmt.Fprintf(w, " **%s**: %s\n", markdownEscaper.Replace(e.Username), text)
+		case "emote", "system":
+			fmt.Fprintf(w, " *%s*\n", text)
+		default:
+			fmt.Fprintf(w, " _%s %s_\n", markdownEscaper.Replace(e.Username), text)
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+var htmlTranscript = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"describe": describe,
+	"datetime": func(t time.Time) string { return t.Format(time.DateTime) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 0.2em 0.6em; vertical-align: top; border-bottom: 1px solid #eee; }
+.meta { color: #888; font-family: monospace; white-space: nowrap; }
+.text { white-space: pre-wrap; }
+.author { font-weight: bold; white-space: nowrap; }
+.emote, .system { font-style: italic; }
+.login, .logout, .nick, .join { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Exported {{datetime .Exported}}, {{len .Events}} events.</p>
+<table>
+{{range .Events}}<tr class="{{.Kind}}">
+<td class="meta">{{.Lamport}}</td>
+<td class="meta">{{datetime .Time}}</td>
+<td class="meta">{{if .Room}}#{{.Room}}{{end}}{{if and .ID (or (eq .Kind "message") (eq .Kind "emote"))}} ({{.ID}}){{end}}</td>
+<td class="author">{{if ne .Kind "emote"}}{{.Username}}{{end}}</td>
+<td class="text">{{describe .}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func WriteHTML(w io.Writer, events []TranscriptEvent, title string) error {
+	return htmlTranscript.Execute(w, struct {
+		Title    string
+		Exported time.Time
+		Events   []TranscriptEvent
+	}{title, time.Now(), events})
+}
+
+func (s *Server) Export(ctx context.Context, req *pb.ExportRequest) (*pb.ExportResponse, error) {
+	client := clientFromContext(ctx)
+	eventTimestamp := s.clock.Sync(clocks.From(req.Timestamp))
+
+	filter := TranscriptFilter{Room: strings.TrimPrefix(req.Room, "#"), Username: req.Username}
+	if req.Since != 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until != 0 {
+		filter.Until = time.Unix(req.Until, 0)
+	}
+
+	room, err := s.readableRoom(client, filter.Room)
+	if err != nil {
+		return nil, err
+	}
+	filter.Room = room
+
+	events := s.transcript.Events(filter)
+
+	title := "ChitChat transcript"
+	if filter.Room != "" {
+		title += " of #" + filter.Room
+	}
+
+	var buf bytes.Buffer
+	switch req.Format {
+	case pb.ExportRequest_JSONL:
+		err = WriteJSONL(&buf, events)
+	case pb.ExportRequest_MARKDOWN:
+		err = WriteMarkdown(&buf, events, title)
+	case pb.ExportRequest_HTML:
+		err = WriteHTML(&buf, events, title)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unknown export format")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to write the transcript: %v", err)
+	}
+
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"export\", username=\"%v\", format=\"%v\", room=\"%v\", user=\"%v\", events=\"%v\"", eventTimestamp, client.username, req.Format, filter.Room, filter.Username, len(events))
+
+	return &pb.ExportResponse{Timestamp: eventTimestamp, Transcript: buf.Bytes(), Events: uint32(len(events))}, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "ChitChat/grpc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTranscriptEventsLatestVersions(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	event := func(lamport uint64, kind string, id uint64, message string) TranscriptEvent {
+		return TranscriptEvent{Lamport: lamport, Time: start.Add(time.Duration(lamport) * time.Minute), Kind: kind, Room: "general", Username: "alice", Message: message, ID: id}
+	}
+
+	transcript := NewTranscript(100)
+	transcript.Load([]TranscriptEvent{
+		event(1, "message", 1, "first"),
+		event(2, "message", 2, "secret"),
+		event(3, "emote", 3, "waves"),
+		event(5, "edit", 1, "first, edited"),
+		event(6, "delete", 2, ""),
+		event(7, "edit", 1, "first, edited twice"),
+		// Recorded out of order, the edit of the emote still comes last
+		event(9, "edit", 3, "waves back"),
+		event(8, "edit", 3, "waves again"),
+		event(10, "message", 4, "last"),
+	})
+
+	events := transcript.Events(TranscriptFilter{})
+	checkIDs(t, events, 1, 3, 4)
+	for i, want := range []string{"first, edited twice", "waves back", "last"} {
+		if events[i].Message != want || events[i].Edited != (i < 2) {
+			t.Errorf("event %d is %q (edited %v), want %q", i, events[i].Message, events[i].Edited, want)
+		}
+	}
+
+	// The edits count even when they are outside the time range
+	events = transcript.Events(TranscriptFilter{Until: start.Add(2 * time.Minute)})
+	checkIDs(t, events, 1)
+	if events[0].Message != "first, edited twice" {
+		t.Errorf("got %q, want the latest text", events[0].Message)
+	}
+}
+
+func TestExport(t *testing.T) {
+	s := newTestServer(t)
+	s.store.SetRole("mod", Moderator)
+	s.transcript.Load([]TranscriptEvent{
+		{Lamport: 1, Time: time.Now(), Kind: "message", Room: "general", Username: "alice", Message: "hello", ID: 1},
+		{Lamport: 2, Time: time.Now(), Kind: "message", Room: "secret", Username: "bob", Message: "hidden", ID: 2},
+		{Lamport: 3, Time: time.Now(), Kind: "message", Room: "general", Username: "alice", Message: "oops", ID: 3},
+		{Lamport: 4, Time: time.Now(), Kind: "delete", Room: "general", Username: "alice", ID: 3},
+	})
+
+	alice := recordingClient()
+	mod := &Client{username: "mod", room: defaultRoom}
+
+	tests := []struct {
+		name   string
+		client *Client
+		room   string
+		ids    []uint64
+		code   codes.Code
+	}{
+		{"own room", alice, defaultRoom, []uint64{1}, codes.OK},
+		// Without a room a member gets the room they are in
+		{"no room", alice, "", []uint64{1}, codes.OK},
+		{"another room", alice, "secret", nil, codes.PermissionDenied},
+		{"moderator in another room", mod, "#secret", []uint64{2}, codes.OK},
+		{"moderator in every room", mod, "", []uint64{1, 2}, codes.OK},
+	}
+
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), clientKey{}, test.client)
+		response, err := s.Export(ctx, &pb.ExportRequest{Format: pb.ExportRequest_JSONL, Room: test.room})
+		if code := status.Code(err); code != test.code {
+			t.Errorf("%s: got %v, want %v", test.name, code, test.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		ids := []uint64{}
+		for _, line := range strings.Split(strings.TrimSpace(string(response.Transcript)), "\n") {
+			var e TranscriptEvent
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			ids = append(ids, e.ID)
+		}
+		if !slices.Equal(ids, test.ids) {
+			t.Errorf("%s: exported %v, want %v", test.name, ids, test.ids)
+		}
+	}
+}
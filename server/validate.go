@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pb "ChitChat/grpc"
+	"ChitChat/utils"
+)
+
+// ValidationError is returned by a validation step which rejects a message
+type ValidationError struct {
+	Code   pb.StreamResponse_Error_Code
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// MessageLimits are the limits checked by the validation pipeline, the lengths are counted in runes
+type MessageLimits struct {
+	MinRunes int
+	MaxRunes int
+	// The number of lines of a multiline message, 0 for no limit
+	MaxLines int
+}
+
+// ValidationStep either returns the message, possibly cleaned up, or rejects it with a ValidationError
+type ValidationStep func(message string, limits MessageLimits) (string, error)
+
+// Validator runs every inbound message through its steps in order
+type Validator struct {
+	steps []ValidationStep
+}
+
+func NewValidator(steps ...ValidationStep) *Validator {
+	return &Validator{steps: steps}
+}
+
+// DefaultValidator rejects invalid UTF-8, strips terminal escapes and control characters and then checks the length
+func DefaultValidator() *Validator {
+	return NewValidator(
+		ValidateUTF8,
+		StripEscapes,
+		NormalizeNewlines,
+		StripControls,
+		TrimSpace,
+		CheckLength,
+	)
+}
+
+func (v *Validator) Validate(message string, limits MessageLimits) (string, error) {
+	for _, step := range v.steps {
+		var err error
+		message, err = step(message, limits)
+		if err != nil {
+			return "", err
+		}
+	}
+	return message, nil
+}
+
+func ValidateUTF8(message string, limits MessageLimits) (string, error) {
+	if !utf8.ValidString(message) {
+		return "", &ValidationError{Code: pb.StreamResponse_Error_INVALID_ENCODING, Reason: "message is not valid UTF-8"}
+	}
+	return message, nil
+}
+
+// escapeSequence matches CSI sequences such as colors and cursor movement, OSC sequences such as window titles
+// and the remaining two byte escapes
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)?|\x1b[@-_]`)
+
+// StripEscapes removes terminal escape sequences, so that a message can not take over the terminal of other users
+func StripEscapes(message string, limits MessageLimits) (string, error) {
+	return escapeSequence.ReplaceAllString(message, ""), nil
+}
+
+var newlineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// NormalizeNewlines turns Windows and old Mac line breaks into '\n'
+func NormalizeNewlines(message string, limits MessageLimits) (string, error) {
+	return newlineReplacer.Replace(message), nil
+}
+
+// StripControls removes control characters, including lone escape characters left over by StripEscapes
+// Line breaks are kept, since messages may span several lines
+func StripControls(message string, limits MessageLimits) (string, error) {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' {
+			return -1
+		}
+		return r
+	}, message), nil
+}
+
+func TrimSpace(message string, limits MessageLimits) (string, error) {
+	return strings.TrimSpace(message), nil
+}
+
+func CheckLength(message string, limits MessageLimits) (string, error) {
+	length := utf8.RuneCountInString(message)
+
+	if length == 0 {
+		return "", &ValidationError{Code: pb.StreamResponse_Error_MESSAGE_TOO_SHORT, Reason: "message is empty"}
+	}
+	if length < limits.MinRunes {
+		reason := fmt.Sprintf("message is %d characters long, the minimum is %d", length, limits.MinRunes)
+		return "", &ValidationError{Code: pb.StreamResponse_Error_MESSAGE_TOO_SHORT, Reason: reason}
+	}
+	if length > limits.MaxRunes {
+		reason := fmt.Sprintf("message is %d characters long, the limit is %d", length, limits.MaxRunes)
+		return "", &ValidationError{Code: pb.StreamResponse_Error_MESSAGE_TOO_LONG, Reason: reason}
+	}
+	if lines := strings.Count(message, "\n") + 1; limits.MaxLines > 0 && lines > limits.MaxLines {
+		reason := fmt.Sprintf("message is %d lines long, the limit is %d", lines, limits.MaxLines)
+		return "", &ValidationError{Code: pb.StreamResponse_Error_MESSAGE_TOO_LONG, Reason: reason}
+	}
+
+	return message, nil
+}
+
+// validateMessage runs the message through the validation pipeline and then the content filters of the server
+// Messages rewritten by a filter go through the validation pipeline once more
+// Rejected messages are reported back to the client and "" and false are returned
+func (s *Server) validateMessage(client *Client, eventTimestamp uint64, message string) (string, bool) {
+	limits := MessageLimits{
+		MinRunes: s.minMessageLength,
+		MaxRunes: int(s.messageLimit.Load()),
+		MaxLines: s.maxMessageLines,
+	}
+
+	message, err := s.validator.Validate(message, limits)
+	if err != nil {
+		s.rejectInvalid(client, eventTimestamp, err)
+		return "", false
+	}
+
+	filtered, ok := s.filterMessage(client, eventTimestamp, message)
+	if !ok || filtered == message {
+		return filtered, ok
+	}
+
+	// A rewrite may have made the message invalid again, e.g. by redacting all of it or by a replacement which is
+	// too long or contains escapes
+	filtered, err = s.validator.Validate(filtered, limits)
+	if err != nil {
+		s.rejectInvalid(client, eventTimestamp, err)
+		return "", false
+	}
+	return filtered, true
+}
+
+// validateReason runs the optional reason of a moderation request through the validation pipeline
+// Reasons are announced to everyone, so they are held to the same rules as messages but kept to a single line
+func (s *Server) validateReason(client *Client, eventTimestamp uint64, reason string) (string, bool) {
+	if strings.TrimSpace(reason) == "" {
+		return "", true
+	}
+
+	limits := MessageLimits{
+		MaxRunes: int(s.messageLimit.Load()),
+		MaxLines: 1,
+	}
+
+	reason, err := s.validator.Validate(reason, limits)
+	if err != nil {
+		s.rejectInvalid(client, eventTimestamp, err)
+		return "", false
+	}
+	return reason, true
+}
+
+// rejectInvalid reports the error of the validation pipeline back to the client
+func (s *Server) rejectInvalid(client *Client, eventTimestamp uint64, err error) {
+	utils.LogAndPrint("logical timestamp=\"%v\", component=\"server\", type=\"invalid message\", username=\"%v\", reason=\"%v\"", eventTimestamp, client.username, err)
+
+	if verr, ok := err.(*ValidationError); ok {
+		s.Reject(client, verr.Code, verr.Reason)
+	} else {
+		s.Reject(client, pb.StreamResponse_Error_INVALID_ARGUMENT, err.Error())
+	}
+}
@@ -0,0 +1,379 @@
+package ui
+
+// Terminals send keys as a stream of bytes. Most keys are a single byte or UTF-8 character, while arrows, Home,
+// function keys and the like are escape sequences, which may arrive split over several reads or several at once.
+// A lone Esc can only be told apart from the start of a sequence by waiting a little for the rest of it
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// EscapeTimeout is how long the decoder waits for the rest of an escape sequence before taking the bytes as they are
+const EscapeTimeout = 50 * time.Millisecond
+
+// maxSequenceLength is the longest escape sequence recognised, longer ones are dropped
+const maxSequenceLength = 32
+
+var (
+	pasteStart = []byte("\033[200~")
+	pasteEnd   = []byte("\033[201~")
+)
+
+// Decoder turns the bytes read from the terminal into keys
+type Decoder struct {
+	pending []byte
+	// Set between the start and the end of a bracketed paste
+	pasting bool
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Feed adds the bytes read from the terminal and returns the keys which are complete
+func (d *Decoder) Feed(data []byte) []Key {
+	d.pending = append(d.pending, data...)
+	return d.drain(false)
+}
+
+// Pending reports whether bytes are waiting for the rest of a sequence
+// Flush should be called if nothing else arrives within EscapeTimeout
+func (d *Decoder) Pending() bool {
+	return len(d.pending) > 0
+}
+
+// Flush takes the pending bytes as they are, e.g. a lone ESC becomes the Esc key
+func (d *Decoder) Flush() []Key {
+	return d.drain(true)
+}
+
+func (d *Decoder) drain(flush bool) []Key {
+	keys := []Key{}
+	for len(d.pending) > 0 {
+		var key Key
+		var n int
+		var ok bool
+		if d.pasting {
+			key, n, ok = d.decodePaste(flush)
+		} else {
+			key, n, ok = d.decode(d.pending, flush)
+		}
+
+		if n == 0 {
+			// The rest of the sequence has not arrived yet
+			break
+		}
+		d.pending = d.pending[n:]
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(d.pending) == 0 {
+		d.pending = nil
+	}
+	return keys
+}
+
+// decode decodes the key at the start of buf
+// Returns the key, the number of bytes it took and whether it is a key at all, unknown sequences are consumed
+// without producing a key. Returns 0 bytes if the key is incomplete
+func (d *Decoder) decode(buf []byte, flush bool) (Key, int, bool) {
+	if buf[0] != 27 {
+		return decodeChar(buf, flush)
+	}
+
+	if len(buf) == 1 {
+		if flush {
+			return specialKey(Esc, 0), 1, true
+		}
+		return Key{}, 0, false
+	}
+
+	switch buf[1] {
+	case '[':
+		return d.decodeCSI(buf, flush)
+	case 'O':
+		return decodeSS3(buf, flush)
+	case 27:
+		return specialKey(Esc, 0), 1, true
+	}
+
+	// ESC followed by a key is how terminals send the key with Alt held
+	key, n, ok := decodeChar(buf[1:], flush)
+	if n == 0 {
+		return key, 0, false
+	}
+	key.mod |= ModAlt
+	return key, n + 1, ok
+}
+
+// decodeChar decodes a control character or a UTF-8 encoded character
+func decodeChar(buf []byte, flush bool) (Key, int, bool) {
+	b := buf[0]
+	switch {
+	case b == 3:
+		return specialKey(CtrlC, ModCtrl), 1, true
+	case b == 6:
+		return specialKey(CtrlF, ModCtrl), 1, true
+	case b == 20:
+		return specialKey(CtrlT, ModCtrl), 1, true
+	case b == '\t':
+		return specialKey(Tab, 0), 1, true
+	case b == '\r' || b == '\n':
+		return specialKey(Return, 0), 1, true
+	case b == 127 || b == 8:
+		return specialKey(Backspace, 0), 1, true
+	case b >= 1 && b <= 26:
+		// The remaining Ctrl-letter combinations
+		return Key{letter: rune('a' + b - 1), mod: ModCtrl}, 1, true
+	case b == 31:
+		// Ctrl-_ and Ctrl-/
+		return Key{letter: '_', mod: ModCtrl}, 1, true
+	case b < 32:
+		return Key{}, 1, false
+	case b < utf8.RuneSelf:
+		return Key{letter: rune(b)}, 1, true
+	}
+
+	if !utf8.FullRune(buf) && !flush {
+		return Key{}, 0, false
+	}
+	r, size := utf8.DecodeRune(buf)
+	if r == utf8.RuneError && size <= 1 {
+		return Key{}, 1, false
+	}
+	return Key{letter: r}, size, true
+}
+
+// decodeCSI decodes a control sequence, ESC [ followed by parameters and a final byte
+func (d *Decoder) decodeCSI(buf []byte, flush bool) (Key, int, bool) {
+	end := -1
+	for i := 2; i < len(buf) && i < maxSequenceLength; i++ {
+		if buf[i] >= 0x40 && buf[i] <= 0x7e {
+			end = i
+			break
+		}
+	}
+
+	if end == -1 {
+		switch {
+		case len(buf) >= maxSequenceLength:
+			return Key{}, 2, false
+		case flush && len(buf) == 2:
+			return Key{letter: '[', mod: ModAlt}, 2, true
+		case flush:
+			return Key{}, len(buf), false
+		}
+		return Key{}, 0, false
+	}
+
+	if bytes.HasPrefix(buf, pasteStart) {
+		d.pasting = true
+		return Key{}, len(pasteStart), false
+	}
+
+	params := strings.Split(string(buf[2:end]), ";")
+	number, _ := strconv.Atoi(params[0])
+	mod := Modifier(0)
+	if len(params) > 1 {
+		mod = modifierParam(params[1])
+	}
+
+	switch buf[end] {
+	case 'u':
+		// Terminals which report every key unambiguously send ESC [ <code point> ; <modifiers> u
+		if special, exists := codepointKeys[number]; exists {
+			return specialKey(special, mod), end + 1, true
+		}
+		if number >= ' ' && utf8.ValidRune(rune(number)) {
+			return Key{letter: rune(number), mod: mod}, end + 1, true
+		}
+		return Key{}, end + 1, false
+	case '~':
+		if special, exists := tildeKeys[number]; exists {
+			return specialKey(special, mod), end + 1, true
+		}
+		return Key{}, end + 1, false
+	case 'Z':
+		return specialKey(Tab, ModShift), end + 1, true
+	}
+
+	if special, exists := finalKeys[buf[end]]; exists {
+		return specialKey(special, mod), end + 1, true
+	}
+	return Key{}, end + 1, false
+}
+
+// decodeSS3 decodes ESC O followed by a single byte, which some terminals send for arrows and F1 to F4
+func decodeSS3(buf []byte, flush bool) (Key, int, bool) {
+	if len(buf) < 3 {
+		if flush {
+			return Key{letter: 'O', mod: ModAlt}, 2, true
+		}
+		return Key{}, 0, false
+	}
+
+	if special, exists := finalKeys[buf[2]]; exists {
+		return specialKey(special, 0), 3, true
+	}
+	return Key{}, 3, false
+}
+
+// decodePaste returns the pasted text received so far, the end of the paste is left pending until it is complete
+func (d *Decoder) decodePaste(flush bool) (Key, int, bool) {
+	n := len(d.pending)
+	consumed := n
+	if end := bytes.Index(d.pending, pasteEnd); end >= 0 {
+		d.pasting = false
+		n, consumed = end, end+len(pasteEnd)
+	} else if !flush {
+		// Hold back what may be the start of the end marker or of a character
+		for k := min(len(pasteEnd)-1, n); k > 0; k-- {
+			if bytes.HasPrefix(pasteEnd, d.pending[n-k:]) {
+				n -= k
+				break
+			}
+		}
+		if n > 0 {
+			last := n - 1
+			for last > 0 && last > n-utf8.UTFMax && !utf8.RuneStart(d.pending[last]) {
+				last--
+			}
+			if !utf8.FullRune(d.pending[last:n]) {
+				n = last
+			}
+		}
+		consumed = n
+	}
+
+	if n == 0 {
+		return Key{}, consumed, false
+	}
+	text := strings.ToValidUTF8(string(d.pending[:n]), string(utf8.RuneError))
+	return Key{isSpecial: true, special: Paste, text: text}, consumed, true
+}
+
+// finalKeys maps the final byte of CSI and SS3 sequences to keys
+var finalKeys = map[byte]SpecialKey{
+	'A': ArrowUp,
+	'B': ArrowDown,
+	'C': ArrowRight,
+	'D': ArrowLeft,
+	'H': Home,
+	'F': End,
+	'P': F1,
+	'Q': F2,
+	'R': F3,
+	'S': F4,
+}
+
+// codepointKeys maps the code points of ESC [ <code point> u sequences which are not characters to keys
+var codepointKeys = map[int]SpecialKey{
+	9:   Tab,
+	13:  Return,
+	27:  Esc,
+	127: Backspace,
+}
+
+// tildeKeys maps the number of ESC [ <number> ~ sequences to keys, terminals disagree on Home and End
+var tildeKeys = map[int]SpecialKey{
+	1:  Home,
+	2:  Insert,
+	3:  Delete,
+	4:  End,
+	5:  PageUp,
+	6:  PageDown,
+	7:  Home,
+	8:  End,
+	11: F1,
+	12: F2,
+	13: F3,
+	14: F4,
+	15: F5,
+	17: F6,
+	18: F7,
+	19: F8,
+	20: F9,
+	21: F10,
+	23: F11,
+	24: F12,
+}
+
+// modifierParam decodes the modifier parameter of a sequence, which is one more than a bit mask of the modifiers
+func modifierParam(param string) Modifier {
+	value, err := strconv.Atoi(param)
+	if err != nil || value < 1 {
+		return 0
+	}
+
+	mod := Modifier(0)
+	mask := value - 1
+	if mask&1 != 0 {
+		mod |= ModShift
+	}
+	if mask&2 != 0 {
+		mod |= ModAlt
+	}
+	if mask&4 != 0 {
+		mod |= ModCtrl
+	}
+	return mod
+}
+
+// readInput sends everything read from the file to the channel
+func readInput(file *os.File, ch chan []byte) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			ch <- data
+		}
+		if err != nil {
+			log.Printf("Stopped reading input: %v", err)
+			return
+		}
+	}
+}
+
+func (ui *UI) charReader() {
+	reads := make(chan []byte)
+	go readInput(os.Stdin, reads)
+	resizes := make(chan struct{}, 1)
+	go watchResize(resizes)
+
+	decoder := NewDecoder()
+	var timeout <-chan time.Time
+	for {
+		var keys []Key
+		select {
+		case data := <-reads:
+			keys = decoder.Feed(data)
+		case <-timeout:
+			keys = decoder.Flush()
+		case <-resizes:
+			keys = []Key{specialKey(Resize, 0)}
+		}
+
+		timeout = nil
+		if decoder.Pending() {
+			timeout = time.After(EscapeTimeout)
+		}
+
+		for _, key := range keys {
+			if ui.keyCh == nil {
+				log.Println("Warning: Unhandled key event")
+				continue
+			}
+			ui.keyCh <- key
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func letterKey(r rune, mod Modifier) Key {
+	return Key{letter: r, mod: mod}
+}
+
+func pasteKey(text string) Key {
+	return Key{isSpecial: true, special: Paste, text: text}
+}
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		// The chunks the bytes arrive in, each is fed separately
+		reads []string
+		// Whether the escape timeout runs out after the last read
+		flush bool
+		want  []Key
+	}{
+		{"plain", []string{"ab"}, false, []Key{letterKey('a', 0), letterKey('b', 0)}},
+		{"control characters", []string{"\x01\x03\x10\t\r\x7f"}, false, []Key{
+			letterKey('a', ModCtrl), specialKey(CtrlC, ModCtrl), letterKey('p', ModCtrl),
+			specialKey(Tab, 0), specialKey(Return, 0), specialKey(Backspace, 0),
+		}},
+		{"utf-8", []string{"é世"}, false, []Key{letterKey('é', 0), letterKey('世', 0)}},
+		{"utf-8 split over reads", []string{"\xe4\xb8", "\x96"}, false, []Key{letterKey('世', 0)}},
+		{"invalid utf-8", []string{"a\xffb"}, false, []Key{letterKey('a', 0), letterKey('b', 0)}},
+		{"incomplete utf-8 after the timeout", []string{"\xe4\xb8"}, true, []Key{}},
+
+		{"arrow", []string{"\x1b[A"}, false, []Key{specialKey(ArrowUp, 0)}},
+		{"sequence split over reads", []string{"\x1b", "[", "1;5", "A"}, false, []Key{specialKey(ArrowUp, ModCtrl)}},
+		{"several sequences at once", []string{"\x1b[A\x1b[Bx"}, false, []Key{specialKey(ArrowUp, 0), specialKey(ArrowDown, 0), letterKey('x', 0)}},
+		{"modifiers", []string{"\x1b[1;2D\x1b[1;3C\x1b[1;8H"}, false, []Key{
+			specialKey(ArrowLeft, ModShift), specialKey(ArrowRight, ModAlt), specialKey(Home, ModShift|ModAlt|ModCtrl),
+		}},
+		{"tilde keys", []string{"\x1b[3~\x1b[5;5~\x1b[24~"}, false, []Key{specialKey(Delete, 0), specialKey(PageUp, ModCtrl), specialKey(F12, 0)}},
+		{"ss3", []string{"\x1bOP\x1bOA"}, false, []Key{specialKey(F1, 0), specialKey(ArrowUp, 0)}},
+		{"shift tab", []string{"\x1b[Z"}, false, []Key{specialKey(Tab, ModShift)}},
+		{"code points", []string{"\x1b[97;5u\x1b[13;2u"}, false, []Key{letterKey('a', ModCtrl), specialKey(Return, ModShift)}},
+		{"unknown sequence", []string{"\x1b[99~\x1b[9Xa"}, false, []Key{letterKey('a', 0)}},
+		{"overlong sequence", []string{"\x1b[" + string(make([]byte, maxSequenceLength)) + "a"}, false, []Key{letterKey('a', 0)}},
+
+		{"alt letter", []string{"\x1bb"}, false, []Key{letterKey('b', ModAlt)}},
+		{"alt letter split over reads", []string{"\x1b", "b"}, false, []Key{letterKey('b', ModAlt)}},
+		{"alt backspace", []string{"\x1b\x7f"}, false, []Key{specialKey(Backspace, ModAlt)}},
+		{"lone esc waits", []string{"\x1b"}, false, []Key{}},
+		{"lone esc after the timeout", []string{"\x1b"}, true, []Key{specialKey(Esc, 0)}},
+		{"double esc", []string{"\x1b\x1b"}, true, []Key{specialKey(Esc, 0), specialKey(Esc, 0)}},
+		{"alt [ after the timeout", []string{"\x1b["}, true, []Key{letterKey('[', ModAlt)}},
+		{"alt O after the timeout", []string{"\x1bO"}, true, []Key{letterKey('O', ModAlt)}},
+		{"incomplete sequence after the timeout", []string{"x\x1b[1;"}, true, []Key{letterKey('x', 0)}},
+
+		{"paste", []string{"\x1b[200~hello\nworld\x1b[201~"}, false, []Key{pasteKey("hello\nworld")}},
+		{"paste with escape bytes", []string{"\x1b[200~a\x1b[Ab\x1b\x1b[201~x"}, false, []Key{pasteKey("a\x1b[Ab\x1b"), letterKey('x', 0)}},
+		{"paste end split over reads", []string{"\x1b[200~ab\x1b[20", "1~c"}, false, []Key{pasteKey("ab"), letterKey('c', 0)}},
+		{"paste utf-8 split over reads", []string{"\x1b[200~a\xe4\xb8", "\x96\x1b[201~"}, false, []Key{pasteKey("a"), pasteKey("世")}},
+		{"paste with invalid utf-8", []string{"\x1b[200~a\xffb\x1b[201~"}, false, []Key{pasteKey("a\ufffdb")}},
+		{"paste without its end", []string{"\x1b[200~abc"}, true, []Key{pasteKey("abc")}},
+	}
+
+	for _, test := range tests {
+		d := NewDecoder()
+		got := []Key{}
+		for _, read := range test.reads {
+			got = append(got, d.Feed([]byte(read))...)
+		}
+		if test.flush {
+			got = append(got, d.Flush()...)
+			if d.Pending() {
+				t.Errorf("%s: bytes are still pending after the timeout", test.name)
+			}
+		}
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecoderPending(t *testing.T) {
+	d := NewDecoder()
+	if keys := d.Feed([]byte("\x1b[1")); len(keys) != 0 || !d.Pending() {
+		t.Fatalf("an incomplete sequence gave %+v, pending %v", keys, d.Pending())
+	}
+	if keys := d.Feed([]byte("~")); !slices.Equal(keys, []Key{specialKey(Home, 0)}) || d.Pending() {
+		t.Fatalf("the completed sequence gave %+v, pending %v", keys, d.Pending())
+	}
+}
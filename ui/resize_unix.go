@@ -0,0 +1,23 @@
+//go:build !windows
+
+package ui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchResize notifies the channel whenever the terminal is resized, which the kernel signals with SIGWINCH
+// Notifications are dropped while one is already waiting, as only the latest size matters
+func watchResize(ch chan<- struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	for range signals {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
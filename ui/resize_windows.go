@@ -0,0 +1,30 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+	"time"
+
+	term "golang.org/x/term"
+)
+
+// resizePollInterval is how often the size of the console is checked, Windows has no signal for it
+const resizePollInterval = 250 * time.Millisecond
+
+// watchResize notifies the channel whenever the size of the console changes
+func watchResize(ch chan<- struct{}) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+	for range time.Tick(resizePollInterval) {
+		w, h, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || (w == width && h == height) {
+			continue
+		}
+		width, height = w, h
+
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
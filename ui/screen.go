@@ -0,0 +1,159 @@
+package ui
+
+// The UI draws into a grid of cells rather than straight to the terminal. Render compares it with the grid of the
+// previous frame, which is what the terminal shows, and only sends the cells which changed. Moving the cursor and
+// changing colors is skipped where the terminal is already in the right state
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attributes are the colors and the style a cell is drawn with
+type attributes struct {
+	fg    Color
+	bg    Color
+	style Style
+}
+
+var defaultAttributes = attributes{fg: Default, bg: Default, style: Normal}
+
+// sgr returns the escape sequence which sets the attributes, starting from a reset
+func (attrs attributes) sgr() string {
+	if attrs.style == Normal {
+		return fmt.Sprintf("%s0;%d;%dm", escape, colorEscapeCode(attrs.fg, Foreground), colorEscapeCode(attrs.bg, Background))
+	}
+	return fmt.Sprintf("%s0;%d;%d;%dm", escape, colorEscapeCode(attrs.fg, Foreground), colorEscapeCode(attrs.bg, Background), styleEscapeCode(attrs.style))
+}
+
+// cell is a position on screen. A wide character takes two cells, the second of which is empty with a width of 0
+type cell struct {
+	// The character along with the zero width characters drawn on top of it
+	text  string
+	width int
+	attrs attributes
+}
+
+var blankCell = cell{text: " ", width: 1, attrs: defaultAttributes}
+
+// unknownCell stands for a cell whose contents are not known, it differs from every cell which can be drawn
+var unknownCell = cell{width: -1}
+
+// screen is a grid of cells, row by row
+type screen struct {
+	width  int
+	height int
+	cells  []cell
+}
+
+func newScreen(width int, height int) *screen {
+	s := &screen{width: width, height: height, cells: make([]cell, width*height)}
+	s.clear()
+	return s
+}
+
+func (s *screen) clear() {
+	for i := range s.cells {
+		s.cells[i] = blankCell
+	}
+}
+
+// invalidate marks every cell as unknown, so that diffing against the screen sends every cell
+func (s *screen) invalidate() {
+	for i := range s.cells {
+		s.cells[i] = unknownCell
+	}
+}
+
+func (s *screen) at(row int, column int) *cell {
+	return &s.cells[row*s.width+column]
+}
+
+// set puts the cell at the position, blanking what is left of a wide character it overwrites half of
+func (s *screen) set(row int, column int, c cell) {
+	current := s.at(row, column)
+	if current.width == 0 && column > 0 {
+		*s.at(row, column-1) = blankCell
+	}
+	if current.width == 2 && column+1 < s.width {
+		*s.at(row, column+1) = blankCell
+	}
+	*current = c
+}
+
+// put draws the rune at the position and returns the position after it
+// Like on a terminal, text which reaches the right edge continues on the next row and anything below the last row
+// is dropped
+func (s *screen) put(row int, column int, r rune, attrs attributes) (int, int) {
+	w := RuneWidth(r)
+	if w == 0 {
+		// Drawn on top of the character before, if there is one on this row. Past the right edge that character was
+		// dropped, e.g. a wide character on a screen one cell wide, and the mark goes with it
+		if r >= ' ' && column > 0 && column <= s.width && row < s.height {
+			prev := column - 1
+			if prev > 0 && s.at(row, prev).width == 0 {
+				prev--
+			}
+			s.at(row, prev).text += string(r)
+		}
+		return row, column
+	}
+
+	if column+w > s.width {
+		row, column = row+1, 0
+	}
+	if row >= s.height || w > s.width {
+		return row, column + w
+	}
+
+	s.set(row, column, cell{text: string(r), width: w, attrs: attrs})
+	if w == 2 {
+		s.set(row, column+1, cell{width: 0, attrs: attrs})
+	}
+
+	column += w
+	if column >= s.width {
+		row, column = row+1, 0
+	}
+	return row, column
+}
+
+// diff appends what has to be sent to a terminal showing the previous screen to make it show this one
+// Returns the attributes the terminal is left with
+func (s *screen) diff(previous *screen, out *strings.Builder) attributes {
+	// Where the terminal cursor is and which attributes it draws with, unknown at first
+	cursorRow, cursorColumn := -1, -1
+	attrs := attributes{}
+	attrsKnown := false
+
+	for row := 0; row < s.height; row++ {
+		for column := 0; column < s.width; column++ {
+			// The second half of a wide character is drawn along with the first
+			c := s.at(row, column)
+			if c.width == 0 || *c == *previous.at(row, column) {
+				continue
+			}
+
+			if row != cursorRow || column != cursorColumn {
+				fmt.Fprintf(out, "%s%d;%dH", escape, row+1, column+1)
+			}
+			if !attrsKnown || c.attrs != attrs {
+				out.WriteString(c.attrs.sgr())
+				attrs, attrsKnown = c.attrs, true
+			}
+
+			out.WriteString(c.text)
+
+			cursorRow, cursorColumn = row, column+c.width
+			if cursorColumn >= s.width {
+				// The terminal may or may not have wrapped to the next row
+				cursorRow, cursorColumn = -1, -1
+			}
+		}
+	}
+
+	if !attrsKnown {
+		return defaultAttributes
+	}
+	return attrs
+}
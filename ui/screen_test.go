@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+// rows returns the text of every row of the screen
+func (s *screen) rows() []string {
+	rows := make([]string, s.height)
+	for row := range rows {
+		for column := 0; column < s.width; column++ {
+			rows[row] += s.at(row, column).text
+		}
+	}
+	return rows
+}
+
+func TestScreenPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		column int
+		text   string
+		want   []string
+	}{
+		{"plain", 4, 2, 0, "ab", []string{"ab  ", "    "}},
+		{"wraps at the edge", 3, 2, 1, "abc", []string{" ab", "c  "}},
+		{"wide moves to the next row", 3, 2, 2, "世", []string{"   ", "世 "}},
+		{"combining mark", 3, 1, 0, "e\u0301x", []string{"e\u0301x "}},
+		{"combining mark on a wide character", 3, 1, 0, "世\u0301x", []string{"世\u0301x"}},
+		{"combining mark starting a row", 3, 1, 0, "\u0301a", []string{"a  "}},
+		{"dropped below the last row", 2, 1, 0, "abc\u0301", []string{"ab"}},
+		// A wide character does not fit on a screen one cell wide, the mark on it must not land on another row
+		{"combining mark on a dropped wide character", 1, 2, 0, "世\u0301", []string{" ", " "}},
+		{"combining mark past the edge", 2, 2, 5, "\u0301", []string{"  ", "  "}},
+	}
+
+	for _, test := range tests {
+		s := newScreen(test.width, test.height)
+		row, column := 0, test.column
+		for _, r := range test.text {
+			row, column = s.put(row, column, r, defaultAttributes)
+		}
+
+		got := s.rows()
+		for i := range test.want {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: rows %q, want %q", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestScreenDiff(t *testing.T) {
+	previous := newScreen(3, 2)
+	s := newScreen(3, 2)
+	s.put(0, 1, 'a', defaultAttributes)
+
+	out := &strings.Builder{}
+	s.diff(previous, out)
+	if want := escape + "1;2H" + defaultAttributes.sgr() + "a"; out.String() != want {
+		t.Errorf("diff against a blank screen = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	s.diff(s, out)
+	if out.Len() != 0 {
+		t.Errorf("diff against the same screen = %q, want nothing", out.String())
+	}
+
+	// Nothing is known about an invalidated screen, so every cell is sent including the blank ones
+	previous.invalidate()
+	out.Reset()
+	s.diff(previous, out)
+	want := escape + "1;1H" + defaultAttributes.sgr() + " a " + escape + "2;1H   "
+	if out.String() != want {
+		t.Errorf("diff against an invalidated screen = %q, want %q", out.String(), want)
+	}
+}
@@ -9,8 +9,6 @@ import (
 )
 
 type UI struct {
-	fg Color
-	bg Color
 	row uint
 	column uint
 
@@ -19,7 +17,9 @@ type UI struct {
 
 	prevState *term.State
 
-	buffer *strings.Builder
+	// What is being drawn and what the terminal shows, see Render
+	back *screen
+	front *screen
 
 	keyCh chan Key
 }
@@ -59,73 +59,19 @@ const (
 // \u001B[
 const escape = "\033["
 
-func (ui *UI) charReader() {
-//	reader := bufio.NewReader(os.Stdin)
-	buf := make([]byte, 3)
-	for {
-//		char, _, _ := reader.ReadRune()
-		n, _ := os.Stdin.Read(buf[:])
-
-//		log.Printf("KEY PRESS: %v - %c", buf, rune(buf[0]))
-		if ui.keyCh == nil {
-			log.Println("Warning: Unhandled key event")
-			continue
-		}
-
-		if n == 1 {
-			switch {
-			case buf[0] == 3:
-				ui.keyCh <- Key{isSpecial: true, special: CtrlC }
-			case buf[0] == 127:
-				ui.keyCh <- Key{isSpecial: true, special: Backspace }
-			case buf[0] == 27:
-				ui.keyCh <- Key{isSpecial: true, special: Esc }
-			case buf[0] == '\n' || buf[0] == '\r':
-				ui.keyCh <- Key{isSpecial: true, special: Return }
-			default:
-				ui.keyCh <- Key{isSpecial: false, letter: rune(buf[0]) }
-			}
-		} else if n == 2 {
-			// Exclude 0
-			utf := string(buf[:2])
-			// find a better way to extract a specific rune
-			var ch rune
-			for _, r := range utf {
-				ch = r
-			}
-			ui.keyCh <- Key{isSpecial: false, letter: ch }
-		} else /* n == 3 */  {
-			if buf[0] == 27 && buf[1] == 91 {
-				switch(buf[2]) {
-				case 65:
-					ui.keyCh <- Key{isSpecial: true, special: ArrowUp }
-				case 66:
-					ui.keyCh <- Key{isSpecial: true, special: ArrowDown }
-				case 67:
-					ui.keyCh <- Key{isSpecial: true, special: ArrowRight }
-				case 68:
-					ui.keyCh <- Key{isSpecial: true, special: ArrowLeft }
-				}
-			}
-		}
-
-	}
-}
-
 func NewUI() *UI {
 	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
 	prevState, _ := term.MakeRaw(int(os.Stdout.Fd()))
 
 	ui := new(UI) 
 	*ui = UI {
-		fg: Default, bg: Default,
 		row: 0, column: 0,
 		height: uint(height), width: uint(width),
-		buffer: &strings.Builder{},
 		prevState: prevState,
 		keyCh: nil,
 	}
-	ui.buffer.Reset()
+	// Have pasted text marked, so that it is not mistaken for typing
+	fmt.Print(escape + "?2004h")
 	ui.SetCursor(0, 0)
 	ui.clear()
 
@@ -135,6 +81,7 @@ func NewUI() *UI {
 }
 
 func (ui *UI) TerminateUI() {
+	fmt.Print(escape + "?2004l")
 	term.Restore(int(os.Stdout.Fd()), ui.prevState)
 }
 
@@ -189,9 +136,30 @@ func styleEscapeCode(style Style) uint {
 }
 
 func (ui *UI) updateTerminalDimensions() {
-	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
-	ui.width = uint(width)
-	ui.height = uint(height)
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		// Keep the last known size, the layout divides by the width
+		return
+	}
+	if uint(width) != ui.width || uint(height) != ui.height {
+		ui.width = uint(width)
+		ui.height = uint(height)
+		ui.back = newScreen(width, height)
+		ui.invalidate()
+	}
+}
+
+// UpdateSize reads the size of the terminal again, it should be called on a Resize key before rendering
+// The next Render repaints every cell, as the terminal may have moved or wrapped what was on it
+func (ui *UI) UpdateSize() {
+	ui.updateTerminalDimensions()
+	ui.invalidate()
+}
+
+// invalidate forgets what the terminal shows, so that the next Render sends every cell without clearing it first
+func (ui *UI) invalidate() {
+	ui.front = newScreen(int(ui.width), int(ui.height))
+	ui.front.invalidate()
 }
 
 func colorEscapeCode(color Color, colorType ColorType) uint {
@@ -204,69 +172,34 @@ func colorEscapeCode(color Color, colorType ColorType) uint {
 	}
 }
 
-func (ui *UI) writeText(str string) {
-	ui.buffer.WriteString(str)
-//	for _, c := range str {
-//		if c == '\n' || c == '\t' { c = ' ' }
-//		ui.buffer.WriteRune(c)
-//	}
-
-	ui.row += (ui.column + uint(len(str))) / ui.width
-	ui.column = (ui.column + uint(len(str))) % ui.width
-}
-
-
-func (ui *UI) writeStyledText(str string, style Style) {
-	code := styleEscapeCode(style)
-
-	ui.writeEscape(fmt.Sprintf("%dm", code))
-	ui.writeText(str)
-
-	// +20 offset indicates the end marker for the styling
-	ui.writeEscape(fmt.Sprintf("%dm", code+20))
-}
-
-func (ui *UI) writeEscape(str string) {
-	ui.buffer.WriteString(escape)
-	ui.writeText(str)
-}
-
-func (ui *UI) writeColor(color Color, colorType ColorType) {
-	ui.writeEscape(fmt.Sprintf("%dm", colorEscapeCode(color, colorType)))
+// writeText draws the text into the next frame from the cursor on, moving the cursor past it
+func (ui *UI) writeText(str string, attrs attributes) {
+	row, column := int(ui.row), int(ui.column)
+	for _, c := range str {
+		row, column = ui.back.put(row, column, c, attrs)
+	}
+	ui.row, ui.column = uint(row), uint(column)
 }
 
+// clear blanks the terminal, so that the next Render draws every cell
 func (ui *UI) clear() {
-	ui.writeEscape("2J")
+	fmt.Print(escape + "0m" + escape + "2J")
+	ui.front = newScreen(int(ui.width), int(ui.height))
+	ui.back = newScreen(int(ui.width), int(ui.height))
 }
 
 func (ui *UI) SetCursor(row uint, column uint) {
 	ui.row = row
 	ui.column = column
-	ui.writeEscape(fmt.Sprintf("%d;%df", ui.row+1, ui.column+1))
-//	ui.writeEscape("0m")
 }
 
 func (ui *UI) Write(text string, fgColor Color, bgColor Color, style Style) {
-	if fgColor != ui.fg {
-		ui.writeColor(fgColor, Foreground)
-	}
-
-	if bgColor != ui.bg {
-		ui.writeColor(bgColor, Background)
-	}
-
-	if style == Normal {
-		ui.writeText(text)
-	} else {
-		ui.writeStyledText(text, style)
-	}
-
-	ui.writeEscape("0m")
+	ui.writeText(text, attributes{fg: fgColor, bg: bgColor, style: style})
 }
 
 func (ui *UI) WriteCentered(text string, fgColor Color, bgColor Color, style Style) {
-	halfLen := uint(len(text) / 2)
-	ui.SetCursor(ui.row, ui.width/2 - halfLen)
+	halfLen := uint(StringWidth(text) / 2)
+	ui.SetCursor(ui.row, ui.width/2 - min(halfLen, ui.width/2))
 	ui.Write(text, fgColor, bgColor, style)
 }
 
@@ -282,13 +215,26 @@ func (ui *UI) GetCursor() (uint, uint) {
 	return ui.row, ui.column
 }
 
+// Render sends the cells which changed since the last frame to the terminal and leaves its cursor where the UI
+// cursor is. The next frame starts out blank
 func (ui *UI) Render() {
-	fmt.Print(ui.buffer.String())
-	ui.buffer.Reset()
-	ui.clear()
+	os.Stdout.WriteString(ui.frame())
 	ui.updateTerminalDimensions()
 }
 
+// frame returns what has to be sent to the terminal to show what was drawn, and starts the next frame
+func (ui *UI) frame() string {
+	out := &strings.Builder{}
+	if ui.back.diff(ui.front, out) != defaultAttributes {
+		out.WriteString(escape + "0m")
+	}
+	fmt.Fprintf(out, "%s%d;%dH", escape, ui.row+1, ui.column+1)
+
+	ui.front, ui.back = ui.back, ui.front
+	ui.back.clear()
+	return out.String()
+}
+
 func (ui *UI) SetKeyChannel(ch chan Key) {
 	ui.keyCh = ch
 }
@@ -298,6 +244,22 @@ type Key struct {
 	special SpecialKey
 	letter rune
 	isSpecial bool
+
+	mod Modifier
+	// The text of a Paste
+	text string
+}
+
+// Modifier is a bit mask of the modifier keys held with a key
+type Modifier uint8
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+func specialKey(special SpecialKey, mod Modifier) Key {
+	return Key{isSpecial: true, special: special, mod: mod}
 }
 
 func (key *Key) IsSpecial() bool {
@@ -318,6 +280,21 @@ func (key *Key) GetLetter() rune {
 	return key.letter
 }
 
+func (key *Key) GetText() string {
+	if !key.IsSpecial() || key.special != Paste {
+		log.Fatalln("Attempted to get text on non-paste key")
+	}
+	return key.text
+}
+
+func (key *Key) Modifiers() Modifier {
+	return key.mod
+}
+
+func (key *Key) HasModifier(mod Modifier) bool {
+	return key.mod&mod != 0
+}
+
 type SpecialKey uint8
 const (
 	CtrlC SpecialKey = iota
@@ -329,4 +306,33 @@ const (
 	ArrowDown
 	ArrowRight
 	ArrowLeft
+
+	CtrlT
+	CtrlF
+
+	PageUp
+	PageDown
+	Home
+	End
+
+	Tab
+	Insert
+	Delete
+	F1
+	F2
+	F3
+	F4
+	F5
+	F6
+	F7
+	F8
+	F9
+	F10
+	F11
+	F12
+
+	// Text pasted into the terminal, see Key.GetText
+	Paste
+	// The terminal changed its size, see UI.UpdateSize
+	Resize
 )
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"unicode/utf8"
+)
+
+// benchInput is the text of a TextInput with the cursor at its end
+type benchInput string
+
+func (t benchInput) String() string {
+	return string(t)
+}
+
+func (t benchInput) Cursor() uint {
+	return uint(utf8.RuneCountInString(string(t)))
+}
+
+func (t benchInput) Selection() (uint, uint, bool) {
+	return 0, 0, false
+}
+
+// chatScreen lays out a screen like the chat of the client, with the text being typed in the input
+func chatScreen(messages []ListItem, typed string) Widget {
+	return NewSplit(Vertical,
+		SplitItem{Widget: &StatusBar{Left: "ChitChat", Right: "alice", Fg: White, Bg: Blue}, Size: 1},
+		SplitItem{Widget: NewSplit(Horizontal,
+			SplitItem{Widget: &Box{Title: "#general", Child: &List{Items: messages}}},
+			SplitItem{Widget: &Box{Title: "Users", Child: &List{Items: []ListItem{&Label{Text: "alice"}, &Label{Text: "bob"}}}}, Size: 22},
+		)},
+		SplitItem{Widget: &TextInput{Prompt: "> ", PromptColor: Green, Text: benchInput(typed), MaxRows: 5}, Size: 1},
+	)
+}
+
+// BenchmarkRender draws a chat screen once per keystroke and reports the bytes sent to the terminal per frame,
+// both when every frame is painted in full and when only the cells which changed are sent
+func BenchmarkRender(b *testing.B) {
+	messages := []ListItem{}
+	for i := 0; i < 30; i++ {
+		messages = append(messages, &Label{Text: fmt.Sprintf("bob @ %d #%d: message number %d with some text", i*2, i, i), Fg: Cyan})
+	}
+	const line = "the quick brown fox jumps over the lazy dog "
+
+	for _, full := range []bool{true, false} {
+		name := "diff"
+		if full {
+			name = "full"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			ui := &UI{width: 80, height: 24}
+			ui.back = newScreen(80, 24)
+			ui.invalidate()
+
+			bytes := 0
+			for i := 0; i < b.N; i++ {
+				if full {
+					ui.invalidate()
+				}
+				ui.Draw(chatScreen(messages, line[:i%len(line)]))
+				bytes += len(ui.frame())
+			}
+			b.ReportMetric(float64(bytes)/float64(b.N), "bytes/frame")
+		})
+	}
+}
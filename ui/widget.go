@@ -0,0 +1,459 @@
+package ui
+
+// Widgets divide the screen between them instead of positioning text by hand. A frame is drawn in two passes:
+// Layout hands every widget its area, starting from the whole screen, and Draw then fills the areas. Both run on
+// every frame, so the layout follows the size of the terminal whenever it is resized
+
+import "strings"
+
+// Rect is an area of the screen
+type Rect struct {
+	Row    int
+	Column int
+	Width  int
+	Height int
+}
+
+// Inset returns the area shrunk by n cells on every side
+func (r Rect) Inset(n int) Rect {
+	return Rect{Row: r.Row + n, Column: r.Column + n, Width: max(r.Width-2*n, 0), Height: max(r.Height-2*n, 0)}
+}
+
+// intersect returns the part of the area which is also within the other one
+func (r Rect) intersect(other Rect) Rect {
+	row, column := max(r.Row, other.Row), max(r.Column, other.Column)
+	bottom := min(r.Row+r.Height, other.Row+other.Height)
+	right := min(r.Column+r.Width, other.Column+other.Width)
+	return Rect{Row: row, Column: column, Width: max(right-column, 0), Height: max(bottom-row, 0)}
+}
+
+// Canvas draws into an area of the next frame, positions are relative to the area and nothing is drawn outside it
+type Canvas struct {
+	ui   *UI
+	area Rect
+
+	// Where the next Write starts
+	row    int
+	column int
+}
+
+func (c *Canvas) Width() int {
+	return c.area.Width
+}
+
+func (c *Canvas) Height() int {
+	return c.area.Height
+}
+
+// Sub returns a canvas for the area, which is given in screen positions like the areas of Layout
+func (c *Canvas) Sub(area Rect) *Canvas {
+	return &Canvas{ui: c.ui, area: area.intersect(c.area)}
+}
+
+// Move moves where the next Write starts
+func (c *Canvas) Move(row int, column int) {
+	c.row, c.column = row, column
+}
+
+// Write draws the text from where the last Write ended or the position given to Move
+// Text is not wrapped, whatever goes past the right edge is cut off
+func (c *Canvas) Write(text string, fg Color, bg Color, style Style) {
+	attrs := attributes{fg: fg, bg: bg, style: style}
+	for _, r := range text {
+		w := RuneWidth(r)
+		if c.row >= 0 && c.row < c.area.Height && c.column+w <= c.area.Width && (w > 0 || c.column > 0) {
+			c.ui.back.put(c.area.Row+c.row, c.area.Column+c.column, r, attrs)
+		}
+		c.column += w
+	}
+}
+
+// Fill paints the whole area in the background color
+func (c *Canvas) Fill(bg Color) {
+	blank := strings.Repeat(" ", c.area.Width)
+	for row := 0; row < c.area.Height; row++ {
+		c.Move(row, 0)
+		c.Write(blank, Default, bg, Normal)
+	}
+	c.Move(0, 0)
+}
+
+// SetCursor places the terminal cursor at the position once the frame is rendered
+func (c *Canvas) SetCursor(row int, column int) {
+	c.ui.SetCursor(uint(c.area.Row+row), uint(c.area.Column+column))
+}
+
+// Widget is a part of the screen
+type Widget interface {
+	// Layout gives the widget its area, containers divide it between their children
+	Layout(area Rect)
+	// Draw draws the widget into the canvas of the area it was given
+	Draw(c *Canvas)
+}
+
+// Draw lays the widget out over the whole screen and draws it into the next frame, which Render then shows
+func (ui *UI) Draw(root Widget) {
+	area := Rect{Width: int(ui.width), Height: int(ui.height)}
+	root.Layout(area)
+	root.Draw(&Canvas{ui: ui, area: area})
+}
+
+// DrawFunc is a widget drawn by a function, for anything the other widgets do not cover
+type DrawFunc func(c *Canvas)
+
+func (f DrawFunc) Layout(area Rect) {}
+
+func (f DrawFunc) Draw(c *Canvas) {
+	f(c)
+}
+
+// Label is a single row of text, shortened with an ellipsis if it does not fit
+type Label struct {
+	Text  string
+	Fg    Color
+	Bg    Color
+	Style Style
+}
+
+func (l *Label) Layout(area Rect) {}
+
+func (l *Label) Draw(c *Canvas) {
+	c.Write(Truncate(l.Text, c.Width()), l.Fg, l.Bg, l.Style)
+}
+
+// Height makes labels list items, they always take one row
+func (l *Label) Height(width int) int {
+	return 1
+}
+
+// StatusBar is a row filled with its background color, with text on the left and on the right
+// The right text is dropped if both do not fit
+type StatusBar struct {
+	Left  string
+	Right string
+	Fg    Color
+	Bg    Color
+}
+
+func (s *StatusBar) Layout(area Rect) {}
+
+func (s *StatusBar) Draw(c *Canvas) {
+	c.Fill(s.Bg)
+
+	left := Truncate(s.Left, c.Width())
+	c.Move(0, 0)
+	c.Write(left, s.Fg, s.Bg, Bold)
+
+	if right := StringWidth(s.Right); right > 0 && StringWidth(left)+1+right <= c.Width() {
+		c.Move(0, c.Width()-right)
+		c.Write(s.Right, s.Fg, s.Bg, Normal)
+	}
+}
+
+// Box draws a border around its child, with the title in the top border
+type Box struct {
+	Title string
+	Color Color
+	Child Widget
+
+	area Rect
+}
+
+func (b *Box) Layout(area Rect) {
+	b.area = area
+	b.Child.Layout(area.Inset(1))
+}
+
+func (b *Box) Draw(c *Canvas) {
+	width, height := c.Width(), c.Height()
+	if width < 2 || height < 2 {
+		return
+	}
+
+	horizontal := strings.Repeat("─", width-2)
+	c.Move(0, 0)
+	c.Write("┌"+horizontal+"┐", b.Color, Default, Normal)
+	for row := 1; row < height-1; row++ {
+		c.Move(row, 0)
+		c.Write("│", b.Color, Default, Normal)
+		c.Move(row, width-1)
+		c.Write("│", b.Color, Default, Normal)
+	}
+	c.Move(height-1, 0)
+	c.Write("└"+horizontal+"┘", b.Color, Default, Normal)
+
+	if b.Title != "" && width > 6 {
+		c.Move(0, 2)
+		c.Write(" "+Truncate(b.Title, width-6)+" ", b.Color, Default, Bold)
+	}
+
+	b.Child.Draw(c.Sub(b.area.Inset(1)))
+}
+
+// Direction is the way a split stacks its items
+type Direction uint8
+
+const (
+	// Vertical stacks the items from top to bottom
+	Vertical Direction = iota
+	// Horizontal puts the items side by side from left to right
+	Horizontal
+)
+
+// SplitItem is an item of a split with its size in rows or columns
+// Items with a size of 0 share whatever the others leave over
+type SplitItem struct {
+	Widget Widget
+	Size   int
+}
+
+// Split divides its area between its items. Items with a fixed size get it as long as there is room left
+type Split struct {
+	Direction Direction
+	Items     []SplitItem
+
+	areas []Rect
+}
+
+func NewSplit(direction Direction, items ...SplitItem) *Split {
+	return &Split{Direction: direction, Items: items}
+}
+
+func (s *Split) Layout(area Rect) {
+	total := area.Height
+	if s.Direction == Horizontal {
+		total = area.Width
+	}
+
+	fixed, shared := 0, 0
+	for _, item := range s.Items {
+		if item.Size > 0 {
+			fixed += item.Size
+		} else {
+			shared++
+		}
+	}
+	rest := max(total-fixed, 0)
+
+	s.areas = make([]Rect, len(s.Items))
+	offset := 0
+	for i, item := range s.Items {
+		size := item.Size
+		if size <= 0 {
+			// The first shared items get what does not divide evenly
+			size = rest / shared
+			if i < rest%shared {
+				size++
+			}
+		}
+		size = min(size, total-offset)
+
+		if s.Direction == Horizontal {
+			s.areas[i] = Rect{Row: area.Row, Column: area.Column + offset, Width: size, Height: area.Height}
+		} else {
+			s.areas[i] = Rect{Row: area.Row + offset, Column: area.Column, Width: area.Width, Height: size}
+		}
+		offset += size
+		item.Widget.Layout(s.areas[i])
+	}
+}
+
+func (s *Split) Draw(c *Canvas) {
+	for i, item := range s.Items {
+		item.Widget.Draw(c.Sub(s.areas[i]))
+	}
+}
+
+// ListItem is an entry of a list, which may take several rows
+type ListItem interface {
+	// Height returns the number of rows the item takes when it is the given number of cells wide
+	Height(width int) int
+	// Draw draws the item into a canvas of its height
+	Draw(c *Canvas)
+}
+
+// List shows as many items as fit, from the top down. It scrolls from the bottom: the newest items are shown
+// unless some are hidden below by scrolling up. Once the first item is shown the list stops scrolling
+type List struct {
+	Items []ListItem
+	// The number of items hidden below the bottom
+	Scroll int
+
+	area  Rect
+	first int
+	last  int
+}
+
+func (l *List) Layout(area Rect) {
+	l.area = area
+	l.first, l.last = l.window(area.Width, area.Height)
+}
+
+// window returns the range of items which fit when the newest Scroll items are hidden
+func (l *List) window(width int, height int) (int, int) {
+	last := max(len(l.Items)-max(l.Scroll, 0), 0)
+
+	// Walk backwards from the newest item to find the oldest one which still fits
+	first := last
+	used := 0
+	for first > 0 && used+l.Items[first-1].Height(width) <= height {
+		first--
+		used += l.Items[first].Height(width)
+	}
+
+	if first == 0 {
+		for last < len(l.Items) && used+l.Items[last].Height(width) <= height {
+			used += l.Items[last].Height(width)
+			last++
+		}
+	}
+
+	if first == last && last > 0 && height > 0 {
+		// The newest item alone is taller than the list, its top part is shown
+		first--
+	}
+	return first, last
+}
+
+func (l *List) Draw(c *Canvas) {
+	row := l.area.Row
+	for _, item := range l.Items[l.first:l.last] {
+		height := item.Height(l.area.Width)
+		item.Draw(c.Sub(Rect{Row: row, Column: l.area.Column, Width: l.area.Width, Height: height}))
+		row += height
+	}
+}
+
+// Hidden returns the number of items hidden below the bottom by the last layout, which is Scroll unless the list
+// stopped scrolling at the first item
+func (l *List) Hidden() int {
+	return len(l.Items) - l.last
+}
+
+// Shown returns the number of items shown by the last layout
+func (l *List) Shown() int {
+	return l.last - l.first
+}
+
+// InputText is the text edited in a TextInput, positions count runes
+type InputText interface {
+	String() string
+	Cursor() uint
+	// Returns the start and the end of the selected text, false if nothing is selected
+	Selection() (uint, uint, bool)
+}
+
+// TextInput shows the text being edited after a prompt, wrapped and broken at line breaks
+// Rows following the first are indented by the width of the prompt, and the terminal cursor is placed in the text
+type TextInput struct {
+	Prompt      string
+	PromptColor Color
+	Text        InputText
+	// The most rows shown, longer text is scrolled to keep the cursor visible
+	MaxRows int
+}
+
+// inputRow is a row of a TextInput, start is the position of its first character in the text
+type inputRow struct {
+	text  string
+	start uint
+}
+
+// layoutInput breaks the text into rows of at most width cells, at line breaks and wherever the next character
+// would not fit. Returns the rows and the row and cell the cursor is at
+func layoutInput(text []rune, cursor uint, width int) ([]inputRow, int, int) {
+	width = max(width, 1)
+
+	rows := []inputRow{}
+	var row strings.Builder
+	rowStart := uint(0)
+	used := 0
+	cursorRow, cursorColumn := 0, 0
+
+	for i, ch := range text {
+		pos := uint(i)
+		if pos == cursor {
+			cursorRow, cursorColumn = len(rows), used
+		}
+
+		if ch == '\n' {
+			rows = append(rows, inputRow{row.String(), rowStart})
+			row.Reset()
+			rowStart, used = pos+1, 0
+			continue
+		}
+
+		w := RuneWidth(ch)
+		if used > 0 && used+w > width {
+			rows = append(rows, inputRow{row.String(), rowStart})
+			row.Reset()
+			rowStart, used = pos, 0
+			if pos == cursor {
+				cursorRow, cursorColumn = len(rows), 0
+			}
+		}
+		row.WriteRune(ch)
+		used += w
+	}
+
+	if cursor == uint(len(text)) {
+		cursorRow, cursorColumn = len(rows), used
+		if used >= width {
+			// The cursor after a full row is shown at the start of the next one
+			rows = append(rows, inputRow{row.String(), rowStart})
+			row.Reset()
+			rowStart = cursor
+			cursorRow, cursorColumn = len(rows), 0
+		}
+	}
+	rows = append(rows, inputRow{row.String(), rowStart})
+
+	return rows, cursorRow, cursorColumn
+}
+
+// rows returns the rows shown when the input is the given number of cells wide and the row of the cursor in them
+func (t *TextInput) rows(width int) ([]inputRow, int, int) {
+	rows, cursorRow, cursorColumn := layoutInput([]rune(t.Text.String()), t.Text.Cursor(), width-StringWidth(t.Prompt))
+
+	limit := max(t.MaxRows, 1)
+	if len(rows) > limit {
+		first := min(max(cursorRow-limit+1, 0), len(rows)-limit)
+		rows, cursorRow = rows[first:first+limit], cursorRow-first
+	}
+	return rows, cursorRow, cursorColumn
+}
+
+// Height returns the number of rows the input takes when it is the given number of cells wide
+func (t *TextInput) Height(width int) int {
+	rows, _, _ := t.rows(width)
+	return len(rows)
+}
+
+func (t *TextInput) Layout(area Rect) {}
+
+func (t *TextInput) Draw(c *Canvas) {
+	rows, cursorRow, cursorColumn := t.rows(c.Width())
+	indent := StringWidth(t.Prompt)
+
+	c.Move(0, 0)
+	c.Write(t.Prompt, t.PromptColor, Default, Bold)
+
+	from, to, selected := t.Text.Selection()
+	for i, row := range rows {
+		c.Move(i, indent)
+		if !selected {
+			c.Write(row.text, Default, Default, Normal)
+			continue
+		}
+
+		// The selection is shown reversed
+		text := []rune(row.text)
+		start := min(max(from, row.start)-row.start, uint(len(text)))
+		end := min(max(to, row.start)-row.start, uint(len(text)))
+		c.Write(string(text[:start]), Default, Default, Normal)
+		c.Write(string(text[start:end]), Default, Default, Reversed)
+		c.Write(string(text[end:]), Default, Default, Normal)
+	}
+
+	c.SetCursor(cursorRow, indent+cursorColumn)
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+// areaWidget remembers the area it was laid out in
+type areaWidget struct {
+	area Rect
+}
+
+func (w *areaWidget) Layout(area Rect) {
+	w.area = area
+}
+
+func (w *areaWidget) Draw(c *Canvas) {}
+
+// tallItem is a list item of a fixed number of rows
+type tallItem int
+
+func (i tallItem) Height(width int) int {
+	return int(i)
+}
+
+func (i tallItem) Draw(c *Canvas) {}
+
+func TestSplitLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction Direction
+		area      Rect
+		sizes     []int
+		want      []Rect
+	}{
+		{"fixed around shared", Vertical, Rect{Width: 10, Height: 10}, []int{1, 0, 1}, []Rect{
+			{Row: 0, Width: 10, Height: 1}, {Row: 1, Width: 10, Height: 8}, {Row: 9, Width: 10, Height: 1},
+		}},
+		{"uneven shares go to the first", Vertical, Rect{Width: 10, Height: 10}, []int{0, 0, 0}, []Rect{
+			{Row: 0, Width: 10, Height: 4}, {Row: 4, Width: 10, Height: 3}, {Row: 7, Width: 10, Height: 3},
+		}},
+		{"odd width", Horizontal, Rect{Width: 5, Height: 1}, []int{0, 0}, []Rect{
+			{Column: 0, Width: 3, Height: 1}, {Column: 3, Width: 2, Height: 1},
+		}},
+		{"fixed sizes which do not fit", Vertical, Rect{Width: 4, Height: 3}, []int{2, 0, 2}, []Rect{
+			{Row: 0, Width: 4, Height: 2}, {Row: 2, Width: 4, Height: 0}, {Row: 2, Width: 4, Height: 1},
+		}},
+		{"empty area", Vertical, Rect{Width: 4}, []int{1, 0}, []Rect{
+			{Row: 0, Width: 4, Height: 0}, {Row: 0, Width: 4, Height: 0},
+		}},
+		{"offset area", Horizontal, Rect{Row: 2, Column: 5, Width: 7, Height: 3}, []int{0, 3, 0}, []Rect{
+			{Row: 2, Column: 5, Width: 2, Height: 3}, {Row: 2, Column: 7, Width: 3, Height: 3}, {Row: 2, Column: 10, Width: 2, Height: 3},
+		}},
+	}
+
+	for _, test := range tests {
+		widgets := []*areaWidget{}
+		items := []SplitItem{}
+		for _, size := range test.sizes {
+			widget := &areaWidget{}
+			widgets = append(widgets, widget)
+			items = append(items, SplitItem{Widget: widget, Size: size})
+		}
+
+		NewSplit(test.direction, items...).Layout(test.area)
+		for i, widget := range widgets {
+			if widget.area != test.want[i] {
+				t.Errorf("%s: item %d got %+v, want %+v", test.name, i, widget.area, test.want[i])
+			}
+		}
+	}
+}
+
+func TestListWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  []int
+		height int
+		scroll int
+		first  int
+		last   int
+	}{
+		{"empty", nil, 5, 0, 0, 0},
+		{"shorter than the list", []int{1, 1}, 5, 0, 0, 2},
+		{"exactly fits", []int{2, 3}, 5, 0, 0, 2},
+		{"newest shown", []int{1, 1, 1, 1}, 2, 0, 2, 4},
+		{"scrolled", []int{1, 1, 1, 1}, 2, 1, 1, 3},
+		{"negative scroll", []int{1, 1, 1, 1}, 2, -1, 2, 4},
+		// Once the first item is shown the list fills up from below instead
+		{"scrolled to the first", []int{1, 1, 1, 1}, 3, 3, 0, 3},
+		{"scrolled past the first", []int{1, 1, 1, 1}, 3, 10, 0, 3},
+		{"newest taller than the list", []int{1, 5}, 3, 0, 1, 2},
+		{"tall item above", []int{1, 5, 1}, 3, 0, 2, 3},
+		{"no rows", []int{1, 1}, 0, 0, 2, 2},
+	}
+
+	for _, test := range tests {
+		list := &List{Scroll: test.scroll}
+		for _, height := range test.items {
+			list.Items = append(list.Items, tallItem(height))
+		}
+
+		list.Layout(Rect{Width: 10, Height: test.height})
+		if list.first != test.first || list.last != test.last {
+			t.Errorf("%s: shows %d to %d, want %d to %d", test.name, list.first, list.last, test.first, test.last)
+		}
+		if list.Shown() != test.last-test.first || list.Hidden() != len(test.items)-test.last {
+			t.Errorf("%s: %d shown and %d hidden", test.name, list.Shown(), list.Hidden())
+		}
+	}
+}
+
+func TestLayoutInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		cursor uint
+		width  int
+		rows   []inputRow
+		row    int
+		column int
+	}{
+		{"empty", "", 0, 4, []inputRow{{"", 0}}, 0, 0},
+		{"fits", "abc", 3, 4, []inputRow{{"abc", 0}}, 0, 3},
+		{"cursor inside", "abcd", 2, 4, []inputRow{{"abcd", 0}}, 0, 2},
+		// The cursor after a full row starts the next one
+		{"cursor after a full row", "abcd", 4, 4, []inputRow{{"abcd", 0}, {"", 4}}, 1, 0},
+		{"wrapped", "abcdef", 6, 4, []inputRow{{"abcd", 0}, {"ef", 4}}, 1, 2},
+		{"cursor on the wrapped last row", "abcdefg", 5, 4, []inputRow{{"abcd", 0}, {"efg", 4}}, 1, 1},
+		{"cursor at the wrap", "abcdef", 4, 4, []inputRow{{"abcd", 0}, {"ef", 4}}, 1, 0},
+		{"line break", "ab\ncd", 3, 4, []inputRow{{"ab", 0}, {"cd", 3}}, 1, 0},
+		{"trailing line break", "ab\n", 3, 4, []inputRow{{"ab", 0}, {"", 3}}, 1, 0},
+		{"wide character at the edge", "abc世", 4, 4, []inputRow{{"abc", 0}, {"世", 3}}, 1, 2},
+		{"no width", "ab", 2, 0, []inputRow{{"a", 0}, {"b", 1}, {"", 2}}, 2, 0},
+	}
+
+	for _, test := range tests {
+		rows, row, column := layoutInput([]rune(test.text), test.cursor, test.width)
+		if !slices.Equal(rows, test.rows) || row != test.row || column != test.column {
+			t.Errorf("%s: got %q with the cursor at %d,%d, want %q at %d,%d", test.name, rows, row, column, test.rows, test.row, test.column)
+		}
+	}
+}
+
+// drawn draws the widget on a screen of the size and returns its rows
+func drawn(widget Widget, width int, height int) (*UI, []string) {
+	ui := &UI{width: uint(width), height: uint(height)}
+	ui.back = newScreen(width, height)
+	ui.Draw(widget)
+	return ui, ui.back.rows()
+}
+
+func TestDrawBoxedList(t *testing.T) {
+	list := &List{Items: []ListItem{&Label{Text: "one"}, &Label{Text: "two"}, &Label{Text: "three"}}}
+	_, rows := drawn(&Box{Title: "Hi", Child: list}, 12, 4)
+
+	want := []string{
+		"┌─ Hi ─────┐",
+		"│two       │",
+		"│three     │",
+		"└──────────┘",
+	}
+	if !slices.Equal(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestDrawTextInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		maxRows int
+		rows    []string
+		row     uint
+		column  uint
+	}{
+		{"wrapped", "abcdefgh", 2, []string{"> abcdef", "  gh    "}, 1, 4},
+		{"cursor after a full row", "abcdef", 2, []string{"> abcdef", "        "}, 1, 2},
+		// Only the row of the cursor fits, so the text scrolls to it
+		{"scrolled to the cursor", "abcdefgh", 1, []string{"> gh    ", "        "}, 0, 4},
+	}
+
+	for _, test := range tests {
+		ui, rows := drawn(&TextInput{Prompt: "> ", Text: benchInput(test.text), MaxRows: test.maxRows}, 8, 2)
+		if !slices.Equal(rows, test.rows) || ui.row != test.row || ui.column != test.column {
+			t.Errorf("%s: got %q with the cursor at %d,%d, want %q at %d,%d", test.name, rows, ui.row, ui.column, test.rows, test.row, test.column)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ui
+
+// Text is measured in terminal cells rather than bytes or runes. Wide characters such as CJK and most emoji
+// take two cells, while combining marks and other zero width characters take none
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Ellipsis is appended to truncated text
+const Ellipsis = "…"
+
+// RuneWidth returns the number of cells the rune occupies on screen
+func RuneWidth(r rune) int {
+	switch {
+	case r < 32 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf), r >= 0x1f3fb && r <= 0x1f3ff:
+		// Combining marks, variation selectors, joiners and skin tones are drawn on top of the preceding character
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// StringWidth returns the number of cells the text occupies on screen
+func StringWidth(s string) int {
+	total := 0
+	for _, r := range s {
+		total += RuneWidth(r)
+	}
+	return total
+}
+
+// splitWidth splits the text after at most n cells, zero width characters stay with the character they belong to
+func splitWidth(s string, n int) (string, string) {
+	used := 0
+	for i, r := range s {
+		w := RuneWidth(r)
+		if w > 0 && used+w > n {
+			return s[:i], s[i:]
+		}
+		used += w
+	}
+	return s, ""
+}
+
+// Truncate shortens the text to at most the given number of cells, ending it with an ellipsis if anything was cut
+func Truncate(s string, cells int) string {
+	if StringWidth(s) <= cells {
+		return s
+	}
+	if cells < 1 {
+		return ""
+	}
+
+	head, _ := splitWidth(s, cells-StringWidth(Ellipsis))
+	return head + Ellipsis
+}
+
+// Wrap breaks the text into lines of at most lineWidth cells, between words where possible
+// The first line is only firstWidth cells wide, which leaves room for whatever precedes it, e.g. the author
+// of a message. Newlines always start a new line, and words wider than a line are broken where they reach the edge
+func Wrap(text string, firstWidth int, lineWidth int) []string {
+	lineWidth = max(lineWidth, 1)
+
+	lines := []string{}
+	var line strings.Builder
+	used := 0
+	limit := firstWidth
+
+	flush := func() {
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+		line.Reset()
+		used = 0
+		limit = lineWidth
+	}
+
+	if limit <= 0 && text != "" {
+		// Nothing fits after the prefix, so the text starts on the next line
+		flush()
+	}
+
+	for i, paragraph := range strings.Split(text, "\n") {
+		if i > 0 {
+			flush()
+		}
+
+		for _, word := range strings.Fields(paragraph) {
+			w := StringWidth(word)
+			if used > 0 && used+1+w > limit && w <= lineWidth {
+				flush()
+			}
+			if used > 0 {
+				line.WriteByte(' ')
+				used++
+			}
+
+			for used+w > limit {
+				head, rest := splitWidth(word, limit-used)
+				if head == "" && used == 0 {
+					// A wide character on a line narrower than it, it is put on the line regardless
+					head, rest = splitWidth(word, RuneWidth([]rune(word)[0]))
+				}
+				line.WriteString(head)
+				if rest == "" {
+					used += StringWidth(head)
+					word, w = "", 0
+					break
+				}
+				flush()
+				word, w = rest, StringWidth(rest)
+			}
+
+			line.WriteString(word)
+			used += w
+		}
+	}
+	flush()
+
+	return lines
+}
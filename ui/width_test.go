@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'é', 1}, // Precomposed
+		{'世', 2},
+		{'Ａ', 2}, // Fullwidth
+		{'ｱ', 1}, // Halfwidth katakana
+		{'😀', 2},
+		{'\u0301', 0}, // Combining acute accent
+		{'\u20dd', 0}, // Enclosing circle
+		{'\ufe0f', 0}, // Variation selector
+		{'\u200d', 0}, // Zero width joiner
+		{0x1f3fd, 0},  // Skin tone
+		{'\t', 0},
+		{'\x1b', 0},
+		{0x7f, 0},
+		{0x85, 0},
+	}
+
+	for _, test := range tests {
+		if got := RuneWidth(test.r); got != test.want {
+			t.Errorf("RuneWidth(%U) = %d, want %d", test.r, got, test.want)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"e\u0301", 1},
+		{"\u0301", 0},
+		{"世界", 4},
+		{"a世b", 4},
+		{"👍\U0001f3fd", 2},
+		{"👨\u200d👩\u200d👧", 6},
+	}
+
+	for _, test := range tests {
+		if got := StringWidth(test.s); got != test.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", test.s, got, test.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		cells int
+		want  string
+	}{
+		{"abc", 3, "abc"},
+		{"abcd", 3, "ab…"},
+		{"abc", 0, ""},
+		{"abc", -1, ""},
+		{"abcd", 1, "…"},
+		// Combining marks stay with their character
+		{"e\u0301e\u0301e\u0301", 3, "e\u0301e\u0301e\u0301"},
+		{"e\u0301e\u0301e\u0301", 2, "e\u0301…"},
+		// A wide character which does not fit is left out entirely
+		{"世界", 4, "世界"},
+		{"世界", 3, "世…"},
+		{"世界", 2, "…"},
+		{"a世界", 3, "a…"},
+	}
+
+	for _, test := range tests {
+		got := Truncate(test.s, test.cells)
+		if got != test.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", test.s, test.cells, got, test.want)
+		}
+		if StringWidth(got) > max(test.cells, 0) {
+			t.Errorf("Truncate(%q, %d) = %q is %d cells wide", test.s, test.cells, got, StringWidth(got))
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		firstWidth int
+		lineWidth  int
+		want       []string
+	}{
+		{"empty", "", 5, 5, []string{""}},
+		{"fits", "hello world", 11, 11, []string{"hello world"}},
+		{"between words", "hello world", 8, 8, []string{"hello", "world"}},
+		{"narrow first line", "aaa bbb", 2, 4, []string{"aa", "a", "bbb"}},
+		{"no room on the first line", "hi", 0, 5, []string{"", "hi"}},
+		{"long word", "abcdefgh", 3, 3, []string{"abc", "def", "gh"}},
+		{"newlines", "a\n\nb", 5, 5, []string{"a", "", "b"}},
+		{"spaces are collapsed", "a   b ", 5, 5, []string{"a b"}},
+
+		// A wide character never straddles the edge, the line ends one cell short instead
+		{"wide at the wrap column", "abc世", 4, 4, []string{"abc", "世"}},
+		{"wide after the wrap column", "a世b", 2, 2, []string{"a", "世", "b"}},
+		{"wide word moves down", "ab 世界", 4, 4, []string{"ab", "世界"}},
+		{"wide wider than the line", "世界", 1, 1, []string{"世", "界"}},
+
+		// Combining marks stay on the line of the character they belong to
+		{"combining at the wrap column", "ab\u0301c", 2, 2, []string{"ab\u0301", "c"}},
+		{"combining after a wide character", "世\u0301界", 2, 2, []string{"世\u0301", "界"}},
+		{"combining starting the text", "\u0301abc", 2, 2, []string{"\u0301ab", "c"}},
+		{"combining starting a line", "x\n\u0301y", 5, 5, []string{"x", "\u0301y"}},
+	}
+
+	for _, test := range tests {
+		got := Wrap(test.text, test.firstWidth, test.lineWidth)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: Wrap(%q, %d, %d) = %q, want %q", test.name, test.text, test.firstWidth, test.lineWidth, got, test.want)
+		}
+	}
+}
@@ -1,10 +0,0 @@
-package utils
-
-import "log"
-
-func Assert(cond bool, message string) {
-	if !cond {
-		log.Fatalln(message)
-	}
-}
-
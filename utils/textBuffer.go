@@ -0,0 +1,376 @@
+package utils
+
+// A gap buffer keeps the text in one slice with a hole at the cursor, so typing and deleting at the cursor only
+// touch the hole. Moving the cursor moves the hole, which copies the runes in between
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+var (
+	ErrBufferFull = errors.New("the text is too long")
+	ErrOutOfRange = errors.New("position out of range")
+)
+
+// undoLimit is the number of edits which can be undone
+const undoLimit = 100
+
+// minGap is the smallest hole the buffer grows by
+const minGap = 16
+
+// edit is a change of the text, which is undone by putting back what was removed in place of what was inserted
+type edit struct {
+	pos      uint
+	removed  []rune
+	inserted []rune
+	// Where the cursor was before the edit
+	cursor uint
+}
+
+// TextBuffer is a growable text with a cursor, a selection and undo/redo
+type TextBuffer struct {
+	data     []rune
+	gapStart uint
+	gapEnd   uint
+	// The most runes the text may have, 0 for no limit
+	limit uint
+
+	// The other end of the selection, the cursor being the first, or -1 if nothing is selected
+	anchor int
+
+	undo []edit
+	redo []edit
+	// Whether the next edit may be joined with the last one, so that typing a word is undone at once
+	merge bool
+}
+
+func NewTextBuffer(limit uint) *TextBuffer {
+	return &TextBuffer{limit: limit, anchor: -1}
+}
+
+func (b *TextBuffer) Len() uint {
+	return uint(len(b.data)) - (b.gapEnd - b.gapStart)
+}
+
+func (b *TextBuffer) Limit() uint {
+	return b.limit
+}
+
+// Cursor returns the position of the cursor, which is in front of the rune at that position
+func (b *TextBuffer) Cursor() uint {
+	return b.gapStart
+}
+
+func (b *TextBuffer) String() string {
+	return string(b.Runes())
+}
+
+// Runes returns a copy of the text
+func (b *TextBuffer) Runes() []rune {
+	text := make([]rune, 0, b.Len())
+	text = append(text, b.data[:b.gapStart]...)
+	return append(text, b.data[b.gapEnd:]...)
+}
+
+// At returns the rune at the position
+func (b *TextBuffer) At(pos uint) (rune, error) {
+	if pos >= b.Len() {
+		return 0, fmt.Errorf("%w: %d with length %d", ErrOutOfRange, pos, b.Len())
+	}
+	if pos < b.gapStart {
+		return b.data[pos], nil
+	}
+	return b.data[pos+b.gapEnd-b.gapStart], nil
+}
+
+// Slice returns the text between the two positions
+func (b *TextBuffer) Slice(from uint, to uint) (string, error) {
+	if err := b.checkRange(from, to); err != nil {
+		return "", err
+	}
+	return string(b.Runes()[from:to]), nil
+}
+
+func (b *TextBuffer) checkRange(from uint, to uint) error {
+	if from > to || to > b.Len() {
+		return fmt.Errorf("%w: %d to %d with length %d", ErrOutOfRange, from, to, b.Len())
+	}
+	return nil
+}
+
+// moveGap moves the hole to the position, which moves the cursor there
+func (b *TextBuffer) moveGap(pos uint) {
+	if pos < b.gapStart {
+		n := b.gapStart - pos
+		copy(b.data[b.gapEnd-n:b.gapEnd], b.data[pos:b.gapStart])
+		b.gapStart, b.gapEnd = pos, b.gapEnd-n
+	} else if pos > b.gapStart {
+		n := pos - b.gapStart
+		copy(b.data[b.gapStart:], b.data[b.gapEnd:b.gapEnd+n])
+		b.gapStart, b.gapEnd = pos, b.gapEnd+n
+	}
+}
+
+// grow makes the hole at least n runes long
+func (b *TextBuffer) grow(n uint) {
+	if b.gapEnd-b.gapStart >= n {
+		return
+	}
+
+	size := max(uint(len(b.data))*2, b.Len()+n+minGap)
+	data := make([]rune, size)
+	copy(data, b.data[:b.gapStart])
+	tail := uint(len(b.data)) - b.gapEnd
+	copy(data[size-tail:], b.data[b.gapEnd:])
+
+	b.data, b.gapEnd = data, size-tail
+}
+
+// apply replaces n runes at the position with the text and leaves the cursor after it, returns what was removed
+func (b *TextBuffer) apply(pos uint, n uint, text []rune) []rune {
+	b.moveGap(pos)
+	removed := append([]rune(nil), b.data[b.gapEnd:b.gapEnd+n]...)
+	b.gapEnd += n
+
+	b.grow(uint(len(text)))
+	copy(b.data[b.gapStart:], text)
+	b.gapStart += uint(len(text))
+	return removed
+}
+
+// Replace replaces the text between the two positions and moves the cursor after the new text
+// If the limit does not leave room for all of it, as much as fits is inserted and ErrBufferFull is returned
+func (b *TextBuffer) Replace(from uint, to uint, text string) error {
+	if err := b.checkRange(from, to); err != nil {
+		return err
+	}
+
+	var err error
+	runes := []rune(text)
+	if b.limit > 0 && b.Len()-(to-from)+uint(len(runes)) > b.limit {
+		runes = runes[:b.limit-(b.Len()-(to-from))]
+		err = ErrBufferFull
+	}
+	if from == to && len(runes) == 0 {
+		b.SetCursor(from)
+		return err
+	}
+
+	cursor := b.Cursor()
+	removed := b.apply(from, to-from, runes)
+	b.record(edit{pos: from, removed: removed, inserted: runes, cursor: cursor})
+	return err
+}
+
+// Insert inserts the text at the cursor, see Replace
+func (b *TextBuffer) Insert(text string) error {
+	return b.Replace(b.Cursor(), b.Cursor(), text)
+}
+
+// Delete removes the text between the two positions, moves the cursor to where it was and returns it
+func (b *TextBuffer) Delete(from uint, to uint) (string, error) {
+	removed, err := b.Slice(from, to)
+	if err != nil {
+		return "", err
+	}
+	return removed, b.Replace(from, to, "")
+}
+
+// Reset removes the text along with the undo history
+func (b *TextBuffer) Reset() {
+	b.gapStart, b.gapEnd = 0, uint(len(b.data))
+	b.anchor = -1
+	b.undo, b.redo = nil, nil
+	b.merge = false
+}
+
+// record adds the edit to the undo history, joining it with the previous one while the same word is typed or deleted
+func (b *TextBuffer) record(e edit) {
+	b.anchor = -1
+	b.redo = nil
+
+	if b.merge && len(b.undo) > 0 {
+		last := &b.undo[len(b.undo)-1]
+		switch {
+		case len(e.removed) == 0 && len(last.removed) == 0 && len(e.inserted) == 1 &&
+			e.pos == last.pos+uint(len(last.inserted)) && !startsWord(last.inserted, e.inserted[0]):
+			last.inserted = append(last.inserted, e.inserted...)
+			return
+		case len(e.inserted) == 0 && len(last.inserted) == 0 && len(e.removed) == 1 && e.pos+1 == last.pos:
+			// Backspace
+			last.removed = append(e.removed, last.removed...)
+			last.pos = e.pos
+			return
+		case len(e.inserted) == 0 && len(last.inserted) == 0 && len(e.removed) == 1 && e.pos == last.pos:
+			// Delete
+			last.removed = append(last.removed, e.removed...)
+			return
+		}
+	}
+
+	b.undo = append(b.undo, e)
+	if len(b.undo) > undoLimit {
+		b.undo = b.undo[1:]
+	}
+	b.merge = true
+}
+
+// startsWord reports whether typing the rune after the text starts a new word, or a new line
+func startsWord(text []rune, r rune) bool {
+	if r == '\n' {
+		return true
+	}
+	return !unicode.IsSpace(r) && unicode.IsSpace(text[len(text)-1])
+}
+
+// BreakUndo makes the next edit a separate undo step
+func (b *TextBuffer) BreakUndo() {
+	b.merge = false
+}
+
+// Undo reverts the last edit, returns false if there is nothing to undo
+func (b *TextBuffer) Undo() bool {
+	if len(b.undo) == 0 {
+		return false
+	}
+
+	e := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	b.apply(e.pos, uint(len(e.inserted)), e.removed)
+	b.moveGap(e.cursor)
+	b.redo = append(b.redo, e)
+	b.anchor = -1
+	b.merge = false
+	return true
+}
+
+// Redo applies the last undone edit again, returns false if there is nothing to redo
+func (b *TextBuffer) Redo() bool {
+	if len(b.redo) == 0 {
+		return false
+	}
+
+	e := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	b.apply(e.pos, uint(len(e.removed)), e.inserted)
+	b.undo = append(b.undo, e)
+	b.anchor = -1
+	b.merge = false
+	return true
+}
+
+// SetCursor moves the cursor to the position
+func (b *TextBuffer) SetCursor(pos uint) error {
+	if pos > b.Len() {
+		return fmt.Errorf("%w: %d with length %d", ErrOutOfRange, pos, b.Len())
+	}
+	b.moveGap(pos)
+	b.merge = false
+	return nil
+}
+
+// Left moves the cursor back by one rune, returns false at the start of the text
+func (b *TextBuffer) Left() bool {
+	if b.Cursor() == 0 {
+		return false
+	}
+	b.SetCursor(b.Cursor() - 1)
+	return true
+}
+
+// Right moves the cursor forward by one rune, returns false at the end of the text
+func (b *TextBuffer) Right() bool {
+	if b.Cursor() == b.Len() {
+		return false
+	}
+	b.SetCursor(b.Cursor() + 1)
+	return true
+}
+
+// runeAt returns the rune at a position known to be in range
+func (b *TextBuffer) runeAt(pos uint) rune {
+	r, _ := b.At(pos)
+	return r
+}
+
+// WordStart returns the start of the word before the position, skipping whatever separates them
+// inWord decides which runes words are made of
+func (b *TextBuffer) WordStart(pos uint, inWord func(rune) bool) uint {
+	pos = min(pos, b.Len())
+	for pos > 0 && !inWord(b.runeAt(pos-1)) {
+		pos--
+	}
+	for pos > 0 && inWord(b.runeAt(pos-1)) {
+		pos--
+	}
+	return pos
+}
+
+// WordEnd returns the end of the word after the position, skipping whatever separates them
+func (b *TextBuffer) WordEnd(pos uint, inWord func(rune) bool) uint {
+	for pos < b.Len() && !inWord(b.runeAt(pos)) {
+		pos++
+	}
+	for pos < b.Len() && inWord(b.runeAt(pos)) {
+		pos++
+	}
+	return pos
+}
+
+// LineStart returns the start of the line the position is on
+func (b *TextBuffer) LineStart(pos uint) uint {
+	pos = min(pos, b.Len())
+	for pos > 0 && b.runeAt(pos-1) != '\n' {
+		pos--
+	}
+	return pos
+}
+
+// LineEnd returns the end of the line the position is on, before its line break
+func (b *TextBuffer) LineEnd(pos uint) uint {
+	for pos < b.Len() && b.runeAt(pos) != '\n' {
+		pos++
+	}
+	return pos
+}
+
+// StartSelection selects from the cursor on as it moves, unless a selection is already in progress
+func (b *TextBuffer) StartSelection() {
+	if b.anchor < 0 {
+		b.anchor = int(b.Cursor())
+	}
+}
+
+func (b *TextBuffer) ClearSelection() {
+	b.anchor = -1
+}
+
+// Selection returns the start and the end of the selected text, false if nothing is selected
+func (b *TextBuffer) Selection() (uint, uint, bool) {
+	if b.anchor < 0 || uint(b.anchor) == b.Cursor() {
+		return 0, 0, false
+	}
+	return min(uint(b.anchor), b.Cursor()), max(uint(b.anchor), b.Cursor()), true
+}
+
+// SelectedText returns the selected text, or an empty string
+func (b *TextBuffer) SelectedText() string {
+	from, to, ok := b.Selection()
+	if !ok {
+		return ""
+	}
+	text, _ := b.Slice(from, to)
+	return text
+}
+
+// DeleteSelection removes the selected text and returns it
+func (b *TextBuffer) DeleteSelection() string {
+	from, to, ok := b.Selection()
+	if !ok {
+		return ""
+	}
+	text, _ := b.Delete(from, to)
+	return text
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fuzzTexts are the texts the fuzzer inserts, mixing words, line breaks, wide and combining characters
+var fuzzTexts = []string{"", "a", "b", " ", "\n", "世", "é", "hello world"}
+
+// FuzzTextBuffer applies random edits, cursor movements, selections and undo/redo to a buffer and checks every step
+// against a plain slice of runes. Undoing everything at the end must leave the empty text it started with
+func FuzzTextBuffer(f *testing.F) {
+	f.Add(uint8(0), []byte{8, 1, 8, 1, 8, 3, 3, 3, 4, 4})
+	f.Add(uint8(5), []byte{8, 7, 2, 2, 5, 10, 10, 7, 3, 4, 0, 0, 3, 6})
+	f.Add(uint8(3), []byte{8, 7, 1, 1, 3, 3, 4, 9, 8, 5, 11, 4})
+
+	f.Fuzz(func(t *testing.T, limit uint8, ops []byte) {
+		// Every op records at most one edit, so all of them stay within the undo limit
+		if len(ops) > undoLimit {
+			ops = ops[:undoLimit]
+		}
+
+		b := NewTextBuffer(uint(limit % 32))
+		model := []rune{}
+		cursor, anchor := 0, -1
+		// The text before every undo which has not been redone yet, and every text the buffer has had
+		redo := [][]rune{}
+		seen := map[string]bool{"": true}
+
+		// arg returns the next byte of the input, or 0 once it runs out
+		arg := func() int {
+			if len(ops) == 0 {
+				return 0
+			}
+			a := int(ops[0])
+			ops = ops[1:]
+			return a
+		}
+		// position returns a position in the text, or occasionally one past its end
+		position := func() int {
+			return arg() % (len(model) + 2)
+		}
+
+		// replace mirrors TextBuffer.Replace on the model
+		replace := func(from int, to int, text string) error {
+			if from > to || to > len(model) {
+				return ErrOutOfRange
+			}
+
+			var err error
+			runes := []rune(text)
+			if b.Limit() > 0 && len(model)-(to-from)+len(runes) > int(b.Limit()) {
+				runes = runes[:int(b.Limit())-(len(model)-(to-from))]
+				err = ErrBufferFull
+			}
+			if from == to && len(runes) == 0 {
+				cursor = from
+				return err
+			}
+
+			model = slices.Concat(model[:from], runes, model[to:])
+			cursor, anchor = from+len(runes), -1
+			redo = nil
+			return err
+		}
+
+		for step := 0; len(ops) > 0; step++ {
+			op := arg() % 12
+			before := slices.Clone(model)
+
+			switch op {
+			case 0:
+				from, to, text := position(), position(), fuzzTexts[arg()%len(fuzzTexts)]
+				want := replace(from, to, text)
+				if err := b.Replace(uint(from), uint(to), text); !errors.Is(err, want) {
+					t.Fatalf("step %d: Replace(%d, %d, %q) = %v, want %v", step, from, to, text, err, want)
+				}
+			case 1:
+				from, to := position(), position()
+				var wantText string
+				if from <= to && to <= len(model) {
+					wantText = string(model[from:to])
+				}
+				want := replace(from, to, "")
+				text, err := b.Delete(uint(from), uint(to))
+				if !errors.Is(err, want) || text != wantText {
+					t.Fatalf("step %d: Delete(%d, %d) = %q, %v, want %q, %v", step, from, to, text, err, wantText, want)
+				}
+			case 2:
+				pos := position()
+				err := b.SetCursor(uint(pos))
+				if pos > len(model) {
+					if !errors.Is(err, ErrOutOfRange) {
+						t.Fatalf("step %d: SetCursor(%d) past the end = %v", step, pos, err)
+					}
+				} else {
+					if err != nil {
+						t.Fatalf("step %d: SetCursor(%d) = %v", step, pos, err)
+					}
+					cursor = pos
+				}
+			case 3:
+				if !b.Undo() {
+					break
+				}
+				redo = append(redo, before)
+				model = b.Runes()
+				if !seen[string(model)] {
+					t.Fatalf("step %d: Undo() went to %q, which the text never was", step, string(model))
+				}
+				cursor, anchor = int(b.Cursor()), -1
+			case 4:
+				undone := len(redo) > 0
+				if b.Redo() != undone {
+					t.Fatalf("step %d: Redo() = %v, want %v", step, !undone, undone)
+				}
+				if !undone {
+					break
+				}
+				model, redo = redo[len(redo)-1], redo[:len(redo)-1]
+				cursor, anchor = int(b.Cursor()), -1
+			case 5:
+				b.StartSelection()
+				if anchor < 0 {
+					anchor = cursor
+				}
+			case 6:
+				b.ClearSelection()
+				anchor = -1
+			case 7:
+				var want string
+				if anchor >= 0 && anchor != cursor {
+					from, to := min(anchor, cursor), max(anchor, cursor)
+					want = string(model[from:to])
+					replace(from, to, "")
+				}
+				if got := b.DeleteSelection(); got != want {
+					t.Fatalf("step %d: DeleteSelection() = %q, want %q", step, got, want)
+				}
+			case 8:
+				text := fuzzTexts[arg()%len(fuzzTexts)]
+				want := replace(cursor, cursor, text)
+				if err := b.Insert(text); !errors.Is(err, want) {
+					t.Fatalf("step %d: Insert(%q) = %v, want %v", step, text, err, want)
+				}
+			case 9:
+				b.BreakUndo()
+			case 10:
+				if b.Left() != (cursor > 0) {
+					t.Fatalf("step %d: Left() at %d", step, cursor)
+				}
+				cursor = max(cursor-1, 0)
+			case 11:
+				if b.Right() != (cursor < len(model)) {
+					t.Fatalf("step %d: Right() at %d of %d", step, cursor, len(model))
+				}
+				cursor = min(cursor+1, len(model))
+			}
+
+			seen[string(model)] = true
+			if got := b.String(); got != string(model) {
+				t.Fatalf("step %d (op %d): text %q, want %q", step, op, got, string(model))
+			}
+			if b.Len() != uint(len(model)) || b.Cursor() != uint(cursor) {
+				t.Fatalf("step %d (op %d): length %d and cursor %d, want %d and %d", step, op, b.Len(), b.Cursor(), len(model), cursor)
+			}
+			if b.Limit() > 0 && b.Len() > b.Limit() {
+				t.Fatalf("step %d (op %d): length %d exceeds the limit %d", step, op, b.Len(), b.Limit())
+			}
+			from, to, selected := b.Selection()
+			wantSelected := anchor >= 0 && anchor != cursor
+			if selected != wantSelected || (selected && (from != uint(min(anchor, cursor)) || to != uint(max(anchor, cursor)))) {
+				t.Fatalf("step %d (op %d): selection %d to %d (%v), want anchor %d and cursor %d", step, op, from, to, selected, anchor, cursor)
+			}
+		}
+
+		for b.Undo() {
+		}
+		if b.Len() != 0 || b.Cursor() != 0 {
+			t.Fatalf("undoing everything left %q with the cursor at %d", b.String(), b.Cursor())
+		}
+	})
+}